@@ -4,6 +4,7 @@ go 1.25.3
 
 require (
 	github.com/imroc/req/v3 v3.55.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/tidwall/gjson v1.18.0
 	github.com/xiaoyi510/xbot v1.0.0
 )
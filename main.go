@@ -3,7 +3,7 @@ package main
 import (
 	"github.com/xiaoyi510/xbot"
 
-	_ "mian/plugins/xarr-merchant"
+	xarrmerchant "mian/plugins/xarr-merchant"
 )
 
 func main() {
@@ -13,6 +13,10 @@ func main() {
 	}
 
 	err = xbot.RunAndListen(cfg)
+
+	// 框架退出后关闭插件的后台任务
+	xarrmerchant.Shutdown()
+
 	if err != nil {
 		panic(err)
 	}
@@ -1,17 +1,14 @@
 package xarrmerchant
 
 import (
-	"crypto/md5"
-	"encoding/hex"
-	"encoding/json"
+	"context"
 	"errors"
-	"sort"
+	"fmt"
+	"slices"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
-	"github.com/imroc/req/v3"
 	"github.com/tidwall/gjson"
 	"github.com/xiaoyi510/xbot/storage"
 )
@@ -21,6 +18,13 @@ const (
 	ConnectType = "xbot"
 	// ConfigKey 配置存储key
 	ConfigKey = "merchant:config"
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 10 * time.Second
+	// maxTimeoutSeconds /设置商户超时 允许的最大秒数
+	maxTimeoutSeconds = 300
+	// maxClientTimeout HTTP客户端兜底超时，防止context未设置截止时间时无限等待
+	// 需不小于可设置的最大请求超时，否则会提前截断单次请求
+	maxClientTimeout = (maxTimeoutSeconds + 10) * time.Second
 )
 
 var (
@@ -31,158 +35,227 @@ var (
 // MerchantClient 商户API客户端
 type MerchantClient struct {
 	storage storage.Storage
-	client  *req.Client
+
+	backendsMu sync.Mutex
+	backends   map[string]*backend // 各商户系统的熔断器与时钟偏差
 }
 
 // NewMerchantClient 创建商户API客户端
 func NewMerchantClient(store storage.Storage) *MerchantClient {
 	return &MerchantClient{
-		storage: store,
-		client:  req.C(),
+		storage:  store,
+		backends: map[string]*backend{},
 	}
 }
 
-// GetConfig 获取配置
-func (c *MerchantClient) GetConfig() (*MerchantConfig, error) {
-	configMu.RLock()
-	defer configMu.RUnlock()
-
-	data, err := c.storage.Get(ConfigKey)
-	if err != nil {
-		return nil, err
+// timeoutFor 获取指定接口的请求超时，优先使用按接口设置的值
+func (config *MerchantConfig) timeoutFor(path string) time.Duration {
+	if seconds, ok := config.RequestTimeouts[path]; ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second
 	}
-
-	if data == nil {
-		return nil, errors.New("未配置商户API，请联系管理员设置")
+	if config.Timeout > 0 {
+		return time.Duration(config.Timeout) * time.Second
 	}
+	return DefaultTimeout
+}
 
-	var config MerchantConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
-	}
+// BreakerState 获取指定商户系统的熔断器状态
+func (c *MerchantClient) BreakerState(profile string) BreakerState {
+	return c.backend(profile).breaker.state()
+}
 
-	return &config, nil
+// IsGroupAllowed 检查群聊是否在白名单中
+// 白名单是唯一的准入条件，分配商户系统只决定群聊使用哪个商户系统
+func (c *MerchantClient) IsGroupAllowed(groupID int64) bool {
+	// 如果白名单为空，则不允许任何群
+	return slices.Contains(c.AllowedGroups(), groupID)
 }
 
-// SaveConfig 保存配置
-func (c *MerchantClient) SaveConfig(config *MerchantConfig) error {
+// AllowedGroups 获取群聊白名单
+// 白名单是全局设置，保存在商户系统索引中，不随 /切换商户系统 变化
+func (c *MerchantClient) AllowedGroups() []int64 {
+	index, err := c.GetIndex()
+	if err != nil {
+		return nil
+	}
+	return index.AllowedGroups
+}
+
+// SaveAllowedGroups 保存群聊白名单，不依赖任何商户系统的配置
+func (c *MerchantClient) SaveAllowedGroups(groups []int64) error {
 	configMu.Lock()
 	defer configMu.Unlock()
 
-	data, err := json.Marshal(config)
+	index, err := c.loadIndex()
 	if err != nil {
 		return err
 	}
+	index.AllowedGroups = groups
+	return c.saveIndex(index)
+}
 
-	return c.storage.Set(ConfigKey, data)
+// addSignature 为请求添加签名
+func (c *MerchantClient) addSignature(config *MerchantConfig, clock *clockSkew, params map[string]string) map[string]string {
+	// 添加时间戳(10位)，按服务器时钟偏差校正
+	timestamp := strconv.FormatInt(clock.now().Unix(), 10)
+	params["timestamp"] = timestamp
+
+	// 添加随机串防止请求被重放，每次发送(含重试)都重新生成
+	if config.EnableNonce {
+		params["nonce"] = generateNonce()
+	}
+
+	// 生成签名
+	sign := signParams(params, config.Secret, config.SignType)
+	params["sign"] = sign
+
+	return params
 }
 
-// IsGroupAllowed 检查群聊是否在白名单中
-func (c *MerchantClient) IsGroupAllowed(groupID int64) bool {
-	config, err := c.GetConfig()
+// apiResponse XArrPay 统一响应结构 {code,message,data,redirect}
+type apiResponse struct {
+	Code     int64
+	Message  string
+	Data     gjson.Result
+	Redirect string
+}
+
+// doRequest 统一请求流程: 读取配置 → 签名 → POST → 解析响应
+// 业务失败(code != 200)时返回 *APIError，不做重试，用于绑定、解绑等非幂等接口
+func (c *MerchantClient) doRequest(ctx context.Context, path string, params map[string]string) (*apiResponse, error) {
+	return c.execute(ctx, path, params, noRetryPolicy)
+}
+
+// doQuery 与doRequest相同，但在网络异常或服务端5xx时按退避策略重试
+// 仅用于幂等的查询接口
+func (c *MerchantClient) doQuery(ctx context.Context, path string, params map[string]string) (*apiResponse, error) {
+	return c.execute(ctx, path, params, defaultRetryPolicy)
+}
+
+// execute 经过熔断器按重试策略发送请求
+// 使用的商户系统由context决定，见 WithProfile
+func (c *MerchantClient) execute(ctx context.Context, path string, params map[string]string, policy retryPolicy) (*apiResponse, error) {
+	profile := c.profileForRequest(ctx)
+	config, err := c.GetProfileConfig(profile)
 	if err != nil {
-		return false
+		return nil, err
 	}
+	b := c.backend(profile)
 
-	// 如果白名单为空，则不允许任何群
-	if len(config.AllowedGroups) == 0 {
-		return false
+	if params == nil {
+		params = map[string]string{}
 	}
+	params["connect_type"] = ConnectType
 
-	// 检查群ID是否在白名单中
-	for _, allowedGroup := range config.AllowedGroups {
-		if allowedGroup == groupID {
-			return true
+	resynced := false
+	for attempt := 1; ; attempt++ {
+		if err := b.breaker.allow(); err != nil {
+			return nil, err
 		}
-	}
 
-	return false
-}
+		start := time.Now()
+		resp, err := c.send(ctx, config, b, path, params)
+		failed := isUpstreamFailure(err)
+		b.breaker.record(err)
+
+		// 时间戳被拒绝时同步服务器时间后重发一次
+		// 服务端在校验时间戳阶段即拒绝，请求未被处理，非幂等接口也可安全重发
+		if errors.Is(err, ErrTimestamp) && !resynced {
+			resynced = true
+			if b.clock.state().SyncedAt.After(start) || c.syncServerTime(ctx, config, b) == nil {
+				attempt--
+				continue
+			}
+		}
 
-// generateSign 生成签名
-// sign = md5(参数按key排序后拼接 + secret)
-func generateSign(params map[string]string, secret string) string {
-	// 获取所有key并排序
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		if k != "sign" { // 排除sign字段本身
-			keys = append(keys, k)
+		if !failed || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return resp, wrapUpstream(err)
 		}
-	}
-	sort.Strings(keys)
 
-	// 拼接参数
-	var builder strings.Builder
-	for i, k := range keys {
-		if i > 0 {
-			builder.WriteString("&")
+		if sleepContext(ctx, policy.backoff(attempt)) != nil {
+			return nil, wrapUpstream(err)
 		}
-		builder.WriteString(k)
-		builder.WriteString("=")
-		builder.WriteString(params[k])
 	}
-
-	// 添加secret (直接拼接，不加&)
-	builder.WriteString(secret)
-
-	// 计算MD5
-	hash := md5.Sum([]byte(builder.String()))
-	return hex.EncodeToString(hash[:])
 }
 
-// addSignature 为请求添加签名
-func (c *MerchantClient) addSignature(params map[string]string) (map[string]string, error) {
-	config, err := c.GetConfig()
+// send 签名并发送单次请求，解析统一响应结构
+func (c *MerchantClient) send(ctx context.Context, config *MerchantConfig, b *backend, path string, params map[string]string) (*apiResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.timeoutFor(path))
+	defer cancel()
+
+	httpClient, err := b.httpClient(config)
 	if err != nil {
 		return nil, err
 	}
 
-	// 添加时间戳(10位)
-	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	params["timestamp"] = timestamp
-
-	// 生成签名
-	sign := generateSign(params, config.Secret)
-	params["sign"] = sign
+	// 每次发送都重新签名，保证重试时时间戳有效
+	params = c.addSignature(config, b.clock, params)
 
-	return params, nil
-}
+	resp, err := httpClient.R().
+		SetContext(ctx).
+		SetFormData(params).
+		Post(config.BaseURL + path)
 
-// GetUserInfo 获取用户信息
-func (c *MerchantClient) GetUserInfo(openID string) (*UserInfo, error) {
-	config, err := c.GetConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	params := map[string]string{
-		"open_id":      openID,
-		"connect_type": ConnectType,
-	}
+	// 记录服务器时间，用于校正签名时间戳
+	b.clock.observeHeader(resp.Header)
 
-	params, err = c.addSignature(params)
+	data, err := resp.ToString()
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.R().
-		SetFormData(params).
-		Post(config.BaseURL + "/api/system-api/user/info")
+	if !gjson.Valid(data) {
+		return nil, fmt.Errorf("%w(HTTP %d)", errBadResponse, resp.StatusCode)
+	}
 
-	if err != nil {
-		return nil, err
+	// 开启验签时拒绝未签名或被篡改的响应
+	if config.VerifyResponse {
+		if err := verifyResponseSign(data, config.Secret, config.SignType); err != nil {
+			return nil, err
+		}
 	}
 
-	data, _ := resp.ToString()
-	code := gjson.Get(data, "code").Int()
-	if code != 200 {
-		message := gjson.Get(data, "message").String()
-		return nil, errors.New(message)
+	result := &apiResponse{
+		Code:     gjson.Get(data, "code").Int(),
+		Message:  gjson.Get(data, "message").String(),
+		Data:     gjson.Get(data, "data"),
+		Redirect: gjson.Get(data, "redirect").String(),
+	}
+
+	if result.Code != 200 {
+		return nil, newAPIError(path, result.Code, result.Message, result.Redirect, resp.StatusCode)
+	}
+
+	return result, nil
+}
+
+// userParams 构造以open_id标识用户的请求参数
+func userParams(openID string) map[string]string {
+	return map[string]string{
+		"open_id": openID,
+	}
+}
+
+// GetUserInfo 获取用户信息
+func (c *MerchantClient) GetUserInfo(openID string) (*UserInfo, error) {
+	return c.GetUserInfoContext(context.Background(), openID)
+}
+
+// GetUserInfoContext 获取用户信息，支持context取消
+func (c *MerchantClient) GetUserInfoContext(ctx context.Context, openID string) (*UserInfo, error) {
+	resp, err := c.doQuery(ctx, "/api/system-api/user/info", userParams(openID))
+	if err != nil {
+		return nil, err
 	}
 
-	result := gjson.Get(data, "data")
+	result := resp.Data
 	if !result.Exists() {
-		return nil, errors.New("未找到用户信息")
+		// 查询不到数据说明未绑定
+		return nil, ErrNotBound
 	}
 
 	// 能获取到数据就说明已绑定
@@ -197,78 +270,38 @@ func (c *MerchantClient) GetUserInfo(openID string) (*UserInfo, error) {
 
 // GetUserBalance 获取用户余额
 func (c *MerchantClient) GetUserBalance(openID string) (int64, error) {
-	config, err := c.GetConfig()
-	if err != nil {
-		return 0, err
-	}
-
-	params := map[string]string{
-		"open_id":      openID,
-		"connect_type": ConnectType,
-	}
-
-	params, err = c.addSignature(params)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := c.client.R().
-		SetFormData(params).
-		Post(config.BaseURL + "/api/system-api/user/balance")
+	return c.GetUserBalanceContext(context.Background(), openID)
+}
 
+// GetUserBalanceContext 获取用户余额，支持context取消
+func (c *MerchantClient) GetUserBalanceContext(ctx context.Context, openID string) (int64, error) {
+	resp, err := c.doQuery(ctx, "/api/system-api/user/balance", userParams(openID))
 	if err != nil {
 		return 0, err
 	}
 
-	data, _ := resp.ToString()
-	code := gjson.Get(data, "code").Int()
-	if code != 200 {
-		message := gjson.Get(data, "message").String()
-		return 0, errors.New(message)
-	}
-
-	balance := gjson.Get(data, "data.balance").Int()
-	return balance, nil
+	return resp.Data.Get("balance").Int(), nil
 }
 
 // GetUserMealInfo 获取用户套餐信息
 func (c *MerchantClient) GetUserMealInfo(openID string) (*UserMealInfo, error) {
-	config, err := c.GetConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	params := map[string]string{
-		"open_id":      openID,
-		"connect_type": ConnectType,
-	}
-
-	params, err = c.addSignature(params)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.client.R().
-		SetFormData(params).
-		Post(config.BaseURL + "/api/system-api/user/meal-info")
+	return c.GetUserMealInfoContext(context.Background(), openID)
+}
 
+// GetUserMealInfoContext 获取用户套餐信息，支持context取消
+func (c *MerchantClient) GetUserMealInfoContext(ctx context.Context, openID string) (*UserMealInfo, error) {
+	resp, err := c.doQuery(ctx, "/api/system-api/user/meal-info", userParams(openID))
 	if err != nil {
 		return nil, err
 	}
 
-	data, _ := resp.ToString()
-	code := gjson.Get(data, "code").Int()
-	if code != 200 {
-		message := gjson.Get(data, "message").String()
-		return nil, errors.New(message)
-	}
-
-	result := gjson.Get(data, "data")
+	result := resp.Data
 	if !result.Exists() {
 		return nil, errors.New("未找到套餐信息")
 	}
 	// "{\"code\":200,\"message\":\"获取成功\",\"data\":{\"expire_time\":-1,\"meal_name\":\"终极会员\",\"rate\":0,\"channel_account_count\":-1,\"day_limit\":-1,\"month_limit\":-1},\"redirect\":\"\"}\n"
 	return &UserMealInfo{
+		MealID:              result.Get("meal_id").Int(),
 		MealName:            result.Get("meal_name").String(),
 		ExpireTime:          result.Get("expire_time").Int(),
 		ChannelAccountCount: int(result.Get("channel_account_count").Int()),
@@ -278,39 +311,78 @@ func (c *MerchantClient) GetUserMealInfo(openID string) (*UserMealInfo, error) {
 	}, nil
 }
 
-// GetUserPayStat 获取用户支付统计
-func (c *MerchantClient) GetUserPayStat(openID string) (*UserPayStat, error) {
-	config, err := c.GetConfig()
+// ListMeals 获取可购买的套餐列表
+func (c *MerchantClient) ListMeals(openID string) ([]Meal, error) {
+	return c.ListMealsContext(context.Background(), openID)
+}
+
+// ListMealsContext 获取可购买的套餐列表，支持context取消
+func (c *MerchantClient) ListMealsContext(ctx context.Context, openID string) ([]Meal, error) {
+	resp, err := c.doQuery(ctx, "/api/system-api/meal/list", userParams(openID))
 	if err != nil {
 		return nil, err
 	}
 
-	params := map[string]string{
-		"open_id":      openID,
-		"connect_type": ConnectType,
+	var meals []Meal
+	for _, item := range resp.Data.Array() {
+		meals = append(meals, Meal{
+			ID:                  item.Get("id").Int(),
+			Name:                item.Get("name").String(),
+			Price:               item.Get("price").Int(),
+			Days:                int(item.Get("days").Int()),
+			ChannelAccountCount: int(item.Get("channel_account_count").Int()),
+			DayLimit:            item.Get("day_limit").Int(),
+			MonthLimit:          item.Get("month_limit").Int(),
+			Rate:                int(item.Get("rate").Int()),
+			Remark:              item.Get("remark").String(),
+		})
 	}
 
-	params, err = c.addSignature(params)
+	return meals, nil
+}
+
+// BuyMeal 使用余额购买套餐，购买当前套餐即为续费
+func (c *MerchantClient) BuyMeal(openID string, mealID int64) (*MealPurchaseResult, error) {
+	return c.BuyMealContext(context.Background(), openID, mealID)
+}
+
+// BuyMealContext 使用余额购买套餐，支持context取消
+// 涉及扣款，不做重试
+func (c *MerchantClient) BuyMealContext(ctx context.Context, openID string, mealID int64) (*MealPurchaseResult, error) {
+	params := userParams(openID)
+	params["meal_id"] = strconv.FormatInt(mealID, 10)
+
+	resp, err := c.doRequest(ctx, "/api/system-api/meal/buy", params)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.R().
-		SetFormData(params).
-		Post(config.BaseURL + "/api/system-api/user/pay-stat")
+	result := resp.Data
+	if !result.Exists() {
+		return nil, errors.New("购买套餐失败: 未返回套餐信息")
+	}
+
+	return &MealPurchaseResult{
+		MealName:   result.Get("meal_name").String(),
+		Amount:     result.Get("amount").Int(),
+		Balance:    result.Get("balance").Int(),
+		ExpireTime: result.Get("expire_time").Int(),
+	}, nil
+}
 
+// GetUserPayStat 获取用户支付统计
+func (c *MerchantClient) GetUserPayStat(openID string) (*UserPayStat, error) {
+	return c.GetUserPayStatContext(context.Background(), openID)
+}
+
+// GetUserPayStatContext 获取用户支付统计，支持context取消
+func (c *MerchantClient) GetUserPayStatContext(ctx context.Context, openID string) (*UserPayStat, error) {
+	resp, err := c.doQuery(ctx, "/api/system-api/user/pay-stat", userParams(openID))
 	if err != nil {
 		return nil, err
 	}
 
-	data, _ := resp.ToString()
-	code := gjson.Get(data, "code").Int()
-	if code != 200 {
-		message := gjson.Get(data, "message").String()
-		return nil, errors.New(message)
-	}
-
-	result := gjson.Get(data, "data")
+	result := resp.Data
 	if !result.Exists() {
 		return nil, errors.New("未找到统计信息")
 	}
@@ -329,124 +401,486 @@ func (c *MerchantClient) GetUserPayStat(openID string) (*UserPayStat, error) {
 
 // GetChannelAccountList 获取渠道账户列表
 func (c *MerchantClient) GetChannelAccountList(openID string) ([]ChannelAccount, error) {
-	config, err := c.GetConfig()
+	return c.GetChannelAccountListContext(context.Background(), openID)
+}
+
+// GetChannelAccountListContext 获取渠道账户列表，支持context取消
+func (c *MerchantClient) GetChannelAccountListContext(ctx context.Context, openID string) ([]ChannelAccount, error) {
+	resp, err := c.doQuery(ctx, "/api/system-api/channel-account/list", userParams(openID))
 	if err != nil {
 		return nil, err
 	}
 
-	params := map[string]string{
-		"open_id":      openID,
-		"connect_type": ConnectType,
+	result := resp.Data
+	if !result.Exists() {
+		return []ChannelAccount{}, nil
 	}
 
-	params, err = c.addSignature(params)
-	if err != nil {
-		return nil, err
+	var accounts []ChannelAccount
+	for _, item := range result.Array() {
+		accounts = append(accounts, parseChannelAccount(item))
 	}
 
-	resp, err := c.client.R().
-		SetFormData(params).
-		Post(config.BaseURL + "/api/system-api/channel-account/list")
+	return accounts, nil
+}
+
+// parseChannelAccount 解析渠道账户信息
+func parseChannelAccount(item gjson.Result) ChannelAccount {
+	return ChannelAccount{
+		ID:             item.Get("id").Int(),
+		Name:           item.Get("name").String(),
+		PayType:        item.Get("pay_type").String(),
+		PayTypeName:    item.Get("pay_type_name").String(),
+		Status:         int(item.Get("status").Int()),
+		Online:         int(item.Get("online").Int()),
+		DayAmount:      item.Get("day_amount").Int(),
+		DayAmountLimit: item.Get("day_amount_limit").Int(),
+	}
+}
 
+// GetChannelAccountDetail 获取渠道账户详情
+func (c *MerchantClient) GetChannelAccountDetail(openID string, accountID int64) (*ChannelAccountDetail, error) {
+	return c.GetChannelAccountDetailContext(context.Background(), openID, accountID)
+}
+
+// GetChannelAccountDetailContext 获取渠道账户详情，支持context取消
+func (c *MerchantClient) GetChannelAccountDetailContext(ctx context.Context, openID string, accountID int64) (*ChannelAccountDetail, error) {
+	params := userParams(openID)
+	params["id"] = strconv.FormatInt(accountID, 10)
+
+	resp, err := c.doQuery(ctx, "/api/system-api/channel-account/detail", params)
 	if err != nil {
 		return nil, err
 	}
 
-	data, _ := resp.ToString()
-	code := gjson.Get(data, "code").Int()
-	if code != 200 {
-		message := gjson.Get(data, "message").String()
-		return nil, errors.New(message)
+	result := resp.Data
+	if !result.Exists() {
+		return nil, errors.New("未找到渠道账户")
 	}
 
-	result := gjson.Get(data, "data")
-	if !result.Exists() {
-		return []ChannelAccount{}, nil
+	detail := &ChannelAccountDetail{
+		ChannelAccount:    parseChannelAccount(result),
+		LastHeartbeat:     result.Get("last_heartbeat").Int(),
+		TodayOrderCount:   result.Get("today_order_count").Int(),
+		TodaySuccessCount: result.Get("today_success_count").Int(),
 	}
 
-	var accounts []ChannelAccount
-	for _, item := range result.Array() {
-		accounts = append(accounts, ChannelAccount{
-			ID:             item.Get("id").Int(),
-			Name:           item.Get("name").String(),
-			PayType:        item.Get("pay_type").String(),
-			PayTypeName:    item.Get("pay_type_name").String(),
-			Status:         int(item.Get("status").Int()),
-			Online:         int(item.Get("online").Int()),
-			DayAmount:      item.Get("day_amount").Int(),
-			DayAmountLimit: item.Get("day_amount_limit").Int(),
+	for _, item := range result.Get("online_logs").Array() {
+		detail.OnlineLogs = append(detail.OnlineLogs, ChannelOnlineLog{
+			Online: int(item.Get("online").Int()),
+			Time:   item.Get("time").Int(),
 		})
 	}
 
-	return accounts, nil
+	for _, item := range result.Get("recent_orders").Array() {
+		detail.RecentOrders = append(detail.RecentOrders, parseOrder(item))
+	}
+
+	return detail, nil
+}
+
+// SetChannelAccountStatus 启用或禁用渠道账户
+func (c *MerchantClient) SetChannelAccountStatus(openID string, accountID int64, enabled bool) error {
+	return c.SetChannelAccountStatusContext(context.Background(), openID, accountID, enabled)
+}
+
+// SetChannelAccountStatusContext 启用或禁用渠道账户，支持context取消
+func (c *MerchantClient) SetChannelAccountStatusContext(ctx context.Context, openID string, accountID int64, enabled bool) error {
+	status := ChannelStatusDisabled
+	if enabled {
+		status = ChannelStatusEnabled
+	}
+
+	params := userParams(openID)
+	params["id"] = strconv.FormatInt(accountID, 10)
+	params["status"] = strconv.Itoa(status)
+
+	_, err := c.doRequest(ctx, "/api/system-api/channel-account/status", params)
+	return err
+}
+
+// SetChannelAccountDayLimit 修改渠道账户单日限额
+func (c *MerchantClient) SetChannelAccountDayLimit(openID string, accountID, limit int64) error {
+	return c.SetChannelAccountDayLimitContext(context.Background(), openID, accountID, limit)
+}
+
+// SetChannelAccountDayLimitContext 修改渠道账户单日限额(分)，支持context取消
+func (c *MerchantClient) SetChannelAccountDayLimitContext(ctx context.Context, openID string, accountID, limit int64) error {
+	params := userParams(openID)
+	params["id"] = strconv.FormatInt(accountID, 10)
+	params["day_amount_limit"] = strconv.FormatInt(limit, 10)
+
+	_, err := c.doRequest(ctx, "/api/system-api/channel-account/day-limit", params)
+	return err
 }
 
 // BindUser 绑定用户
 func (c *MerchantClient) BindUser(ticket, openID string) error {
-	config, err := c.GetConfig()
+	return c.BindUserContext(context.Background(), ticket, openID)
+}
+
+// BindUserContext 绑定用户，支持context取消
+func (c *MerchantClient) BindUserContext(ctx context.Context, ticket, openID string) error {
+	params := userParams(openID)
+	params["ticket"] = ticket
+
+	_, err := c.doRequest(ctx, "/api/system-api/user/bind", params)
+	return err
+}
+
+// UnbindUser 解绑用户
+func (c *MerchantClient) UnbindUser(openID string) error {
+	return c.UnbindUserContext(context.Background(), openID)
+}
+
+// UnbindUserContext 解绑用户，支持context取消
+func (c *MerchantClient) UnbindUserContext(ctx context.Context, openID string) error {
+	_, err := c.doRequest(ctx, "/api/system-api/user/unbind", userParams(openID))
+	return err
+}
+
+// parseOrder 解析订单数据
+func parseOrder(item gjson.Result) Order {
+	return Order{
+		TradeNo:            item.Get("trade_no").String(),
+		OutTradeNo:         item.Get("out_trade_no").String(),
+		Name:               item.Get("name").String(),
+		Amount:             item.Get("amount").Int(),
+		RealAmount:         item.Get("real_amount").Int(),
+		RefundAmount:       item.Get("refund_amount").Int(),
+		PayType:            item.Get("pay_type").String(),
+		PayTypeName:        item.Get("pay_type_name").String(),
+		ChannelAccountID:   item.Get("channel_account_id").Int(),
+		ChannelAccountName: item.Get("channel_account_name").String(),
+		Status:             int(item.Get("status").Int()),
+		NotifyStatus:       int(item.Get("notify_status").Int()),
+		CreateTime:         item.Get("create_time").Int(),
+		PayTime:            item.Get("pay_time").Int(),
+	}
+}
+
+// getOrder 按指定字段查询订单详情
+func (c *MerchantClient) getOrder(ctx context.Context, openID, field, value string) (*Order, error) {
+	params := userParams(openID)
+	params[field] = value
+
+	resp, err := c.doQuery(ctx, "/api/system-api/order/detail", params)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if !resp.Data.Exists() || resp.Data.Type == gjson.Null {
+		return nil, ErrOrderNotFound
 	}
 
-	params := map[string]string{
-		"ticket":       ticket,
-		"open_id":      openID,
-		"connect_type": ConnectType,
+	order := parseOrder(resp.Data)
+	return &order, nil
+}
+
+// GetOrderByTradeNo 按平台订单号查询订单
+func (c *MerchantClient) GetOrderByTradeNo(openID, tradeNo string) (*Order, error) {
+	return c.GetOrderByTradeNoContext(context.Background(), openID, tradeNo)
+}
+
+// GetOrderByTradeNoContext 按平台订单号查询订单，支持context取消
+func (c *MerchantClient) GetOrderByTradeNoContext(ctx context.Context, openID, tradeNo string) (*Order, error) {
+	return c.getOrder(ctx, openID, "trade_no", tradeNo)
+}
+
+// GetOrderByOutTradeNo 按商户订单号查询订单
+func (c *MerchantClient) GetOrderByOutTradeNo(openID, outTradeNo string) (*Order, error) {
+	return c.GetOrderByOutTradeNoContext(context.Background(), openID, outTradeNo)
+}
+
+// GetOrderByOutTradeNoContext 按商户订单号查询订单，支持context取消
+func (c *MerchantClient) GetOrderByOutTradeNoContext(ctx context.Context, openID, outTradeNo string) (*Order, error) {
+	return c.getOrder(ctx, openID, "out_trade_no", outTradeNo)
+}
+
+// FindOrder 按订单号查询订单，先按商户订单号查找，找不到时再按平台订单号查找
+func (c *MerchantClient) FindOrder(openID, orderNo string) (*Order, error) {
+	return c.FindOrderContext(context.Background(), openID, orderNo)
+}
+
+// FindOrderContext 按订单号查询订单，支持context取消
+func (c *MerchantClient) FindOrderContext(ctx context.Context, openID, orderNo string) (*Order, error) {
+	order, err := c.GetOrderByOutTradeNoContext(ctx, openID, orderNo)
+	if !errors.Is(err, ErrOrderNotFound) {
+		return order, err
 	}
+	return c.GetOrderByTradeNoContext(ctx, openID, orderNo)
+}
+
+// ListOrders 分页查询订单列表
+func (c *MerchantClient) ListOrders(openID string, filter OrderFilter) (*OrderPage, error) {
+	return c.ListOrdersContext(context.Background(), openID, filter)
+}
 
-	params, err = c.addSignature(params)
+// ListOrdersContext 分页查询订单列表，支持context取消
+func (c *MerchantClient) ListOrdersContext(ctx context.Context, openID string, filter OrderFilter) (*OrderPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 10
+	}
+
+	params := userParams(openID)
+	params["page"] = strconv.Itoa(filter.Page)
+	params["page_size"] = strconv.Itoa(filter.PageSize)
+	if filter.Status != nil {
+		params["status"] = strconv.Itoa(*filter.Status)
+	}
+	if filter.PayType != "" {
+		params["pay_type"] = filter.PayType
+	}
+	if filter.ChannelAccountID > 0 {
+		params["channel_account_id"] = strconv.FormatInt(filter.ChannelAccountID, 10)
+	}
+	if filter.StartTime > 0 {
+		params["start_time"] = strconv.FormatInt(filter.StartTime, 10)
+	}
+	if filter.EndTime > 0 {
+		params["end_time"] = strconv.FormatInt(filter.EndTime, 10)
+	}
+	if filter.MinAmount > 0 {
+		params["min_amount"] = strconv.FormatInt(filter.MinAmount, 10)
+	}
+	if filter.MaxAmount > 0 {
+		params["max_amount"] = strconv.FormatInt(filter.MaxAmount, 10)
+	}
+
+	resp, err := c.doQuery(ctx, "/api/system-api/order/list", params)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	resp, err := c.client.R().
-		SetFormData(params).
-		Post(config.BaseURL + "/api/system-api/user/bind")
+	result := resp.Data
+	page := &OrderPage{
+		List:     []Order{},
+		Total:    result.Get("total").Int(),
+		Page:     int(result.Get("page").Int()),
+		PageSize: int(result.Get("page_size").Int()),
+	}
+	if page.Page < 1 {
+		page.Page = filter.Page
+	}
+	if page.PageSize < 1 {
+		page.PageSize = filter.PageSize
+	}
 
+	for _, item := range result.Get("list").Array() {
+		page.List = append(page.List, parseOrder(item))
+	}
+
+	return page, nil
+}
+
+// RenotifyOrder 重新发送订单支付通知
+func (c *MerchantClient) RenotifyOrder(openID, tradeNo string) (*NotifyResult, error) {
+	return c.RenotifyOrderContext(context.Background(), openID, tradeNo)
+}
+
+// RenotifyOrderContext 重新发送订单支付通知，支持context取消
+// 会触发商户回调，不做重试
+func (c *MerchantClient) RenotifyOrderContext(ctx context.Context, openID, tradeNo string) (*NotifyResult, error) {
+	params := userParams(openID)
+	params["trade_no"] = tradeNo
+
+	resp, err := c.doRequest(ctx, "/api/system-api/order/renotify", params)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	data, _ := resp.ToString()
-	code := gjson.Get(data, "code").Int()
-	if code != 200 {
-		message := gjson.Get(data, "message").String()
-		return errors.New(message)
+	result := resp.Data
+	return &NotifyResult{
+		Success:    result.Get("success").Bool(),
+		HTTPStatus: int(result.Get("http_status").Int()),
+		Response:   result.Get("response").String(),
+		Duration:   result.Get("duration").Int(),
+		Error:      result.Get("error").String(),
+	}, nil
+}
+
+// RefundOrder 发起退款，amount为退款金额(分)
+func (c *MerchantClient) RefundOrder(openID, tradeNo string, amount int64) (*RefundResult, error) {
+	return c.RefundOrderContext(context.Background(), openID, tradeNo, amount)
+}
+
+// RefundOrderContext 发起退款，支持context取消
+// 每次调用生成新的退款请求号供服务端去重，不做重试
+func (c *MerchantClient) RefundOrderContext(ctx context.Context, openID, tradeNo string, amount int64) (*RefundResult, error) {
+	params := userParams(openID)
+	params["trade_no"] = tradeNo
+	params["amount"] = strconv.FormatInt(amount, 10)
+	params["out_refund_no"] = "R" + strconv.FormatInt(time.Now().UnixMilli(), 10) + generateNonce()[:6]
+
+	resp, err := c.doRequest(ctx, "/api/system-api/order/refund", params)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	result := resp.Data
+	return &RefundResult{
+		RefundNo: result.Get("refund_no").String(),
+		Amount:   result.Get("amount").Int(),
+		Status:   int(result.Get("status").Int()),
+		Message:  result.Get("message").String(),
+	}, nil
 }
 
-// UnbindUser 解绑用户
-func (c *MerchantClient) UnbindUser(openID string) error {
-	config, err := c.GetConfig()
+// parsePaymentOrder 解析创建订单返回的支付信息
+func parsePaymentOrder(item gjson.Result) *PaymentOrder {
+	return &PaymentOrder{
+		TradeNo:    item.Get("trade_no").String(),
+		OutTradeNo: item.Get("out_trade_no").String(),
+		Amount:     item.Get("amount").Int(),
+		PayType:    item.Get("pay_type").String(),
+		PayURL:     item.Get("pay_url").String(),
+		QRCode:     item.Get("qrcode").String(),
+		ExpireTime: item.Get("expire_time").Int(),
+	}
+}
+
+// CreateOrder 创建收款订单
+func (c *MerchantClient) CreateOrder(openID string, req CreateOrderRequest) (*PaymentOrder, error) {
+	return c.CreateOrderContext(context.Background(), openID, req)
+}
+
+// CreateOrderContext 创建收款订单，支持context取消
+// 商户订单号由机器人生成，不做重试
+func (c *MerchantClient) CreateOrderContext(ctx context.Context, openID string, req CreateOrderRequest) (*PaymentOrder, error) {
+	params := userParams(openID)
+	params["out_trade_no"] = "XB" + time.Now().Format("20060102150405") + generateNonce()[:6]
+	params["amount"] = strconv.FormatInt(req.Amount, 10)
+	params["name"] = req.Name
+	if req.PayType != "" {
+		params["pay_type"] = req.PayType
+	}
+
+	resp, err := c.doRequest(ctx, "/api/system-api/order/create", params)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if !resp.Data.Exists() {
+		return nil, errors.New("创建订单失败: 未返回支付信息")
+	}
+
+	return parsePaymentOrder(resp.Data), nil
+}
+
+// CreateRecharge 创建余额充值订单
+func (c *MerchantClient) CreateRecharge(openID string, amount int64, payType string) (*PaymentOrder, error) {
+	return c.CreateRechargeContext(context.Background(), openID, amount, payType)
+}
+
+// CreateRechargeContext 创建余额充值订单，支持context取消
+// 充值订单号由机器人生成，不做重试
+func (c *MerchantClient) CreateRechargeContext(ctx context.Context, openID string, amount int64, payType string) (*PaymentOrder, error) {
+	params := userParams(openID)
+	params["out_trade_no"] = "RC" + time.Now().Format("20060102150405") + generateNonce()[:6]
+	params["amount"] = strconv.FormatInt(amount, 10)
+	if payType != "" {
+		params["pay_type"] = payType
 	}
 
-	params := map[string]string{
-		"connect_type": ConnectType,
+	resp, err := c.doRequest(ctx, "/api/system-api/user/recharge/create", params)
+	if err != nil {
+		return nil, err
 	}
 
-	params, err = c.addSignature(params)
+	if !resp.Data.Exists() {
+		return nil, errors.New("创建充值订单失败: 未返回支付信息")
+	}
+
+	return parsePaymentOrder(resp.Data), nil
+}
+
+// GetRecharge 查询余额充值订单
+func (c *MerchantClient) GetRecharge(openID, tradeNo string) (*RechargeOrder, error) {
+	return c.GetRechargeContext(context.Background(), openID, tradeNo)
+}
+
+// GetRechargeContext 查询余额充值订单，支持context取消
+func (c *MerchantClient) GetRechargeContext(ctx context.Context, openID, tradeNo string) (*RechargeOrder, error) {
+	params := userParams(openID)
+	params["trade_no"] = tradeNo
+
+	resp, err := c.doQuery(ctx, "/api/system-api/user/recharge/detail", params)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	resp, err := c.client.R().
-		SetFormData(params).
-		Post(config.BaseURL + "/api/system-api/user/unbind")
+	result := resp.Data
+	if !result.Exists() {
+		return nil, ErrOrderNotFound
+	}
 
+	return &RechargeOrder{
+		TradeNo: result.Get("trade_no").String(),
+		Amount:  result.Get("amount").Int(),
+		Status:  int(result.Get("status").Int()),
+		Balance: result.Get("balance").Int(),
+		PayTime: result.Get("pay_time").Int(),
+	}, nil
+}
+
+// ListBalanceLogs 分页查询资金流水
+func (c *MerchantClient) ListBalanceLogs(openID string, filter BalanceLogFilter) (*BalanceLogPage, error) {
+	return c.ListBalanceLogsContext(context.Background(), openID, filter)
+}
+
+// ListBalanceLogsContext 分页查询资金流水，支持context取消
+func (c *MerchantClient) ListBalanceLogsContext(ctx context.Context, openID string, filter BalanceLogFilter) (*BalanceLogPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 10
+	}
+
+	params := userParams(openID)
+	params["page"] = strconv.Itoa(filter.Page)
+	params["page_size"] = strconv.Itoa(filter.PageSize)
+	if filter.StartTime > 0 {
+		params["start_time"] = strconv.FormatInt(filter.StartTime, 10)
+	}
+	if filter.EndTime > 0 {
+		params["end_time"] = strconv.FormatInt(filter.EndTime, 10)
+	}
+
+	resp, err := c.doQuery(ctx, "/api/system-api/user/balance-log", params)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	result := resp.Data
+	page := &BalanceLogPage{
+		List:     []BalanceLog{},
+		Total:    result.Get("total").Int(),
+		Page:     int(result.Get("page").Int()),
+		PageSize: int(result.Get("page_size").Int()),
+	}
+	if page.Page < 1 {
+		page.Page = filter.Page
+	}
+	if page.PageSize < 1 {
+		page.PageSize = filter.PageSize
 	}
 
-	data, _ := resp.ToString()
-	code := gjson.Get(data, "code").Int()
-	if code != 200 {
-		message := gjson.Get(data, "message").String()
-		return errors.New(message)
+	for _, item := range result.Get("list").Array() {
+		page.List = append(page.List, BalanceLog{
+			ID:         item.Get("id").Int(),
+			Type:       int(item.Get("type").Int()),
+			TypeName:   item.Get("type_name").String(),
+			Amount:     item.Get("amount").Int(),
+			Before:     item.Get("before").Int(),
+			After:      item.Get("after").Int(),
+			Remark:     item.Get("remark").String(),
+			CreateTime: item.Get("create_time").Int(),
+		})
 	}
 
-	return nil
+	return page, nil
 }
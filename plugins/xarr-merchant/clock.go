@@ -0,0 +1,122 @@
+package xarrmerchant
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// serverTimePath 获取服务器时间的接口
+	serverTimePath = "/api/system-api/time"
+	// skewTolerance Date头只精确到秒，偏差在此范围内视为无偏差
+	skewTolerance = 2 * time.Second
+)
+
+// SkewState 时钟偏差快照
+type SkewState struct {
+	Offset   time.Duration // 服务器时间 - 本机时间
+	SyncedAt time.Time     // 最近一次同步时间，零值表示从未同步
+	Source   string        // 同步来源: Date头/时间接口
+}
+
+// clockSkew 维护本机与商户系统之间的时钟偏差
+type clockSkew struct {
+	mu       sync.RWMutex
+	offset   time.Duration
+	syncedAt time.Time
+	source   string
+}
+
+// now 返回按偏差校正后的当前时间
+func (s *clockSkew) now() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Now().Add(s.offset)
+}
+
+// update 根据服务器时间更新偏差
+func (s *clockSkew) update(serverTime time.Time, source string) {
+	offset := serverTime.Sub(time.Now()).Round(time.Second)
+	if offset > -skewTolerance && offset < skewTolerance {
+		offset = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset = offset
+	s.syncedAt = time.Now()
+	s.source = source
+}
+
+// observeHeader 从响应Date头中获取服务器时间
+// 返回是否成功更新
+func (s *clockSkew) observeHeader(header http.Header) bool {
+	date := header.Get("Date")
+	if date == "" {
+		return false
+	}
+
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return false
+	}
+
+	s.update(serverTime, "Date头")
+	return true
+}
+
+// state 获取状态快照
+func (s *clockSkew) state() SkewState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return SkewState{
+		Offset:   s.offset,
+		SyncedAt: s.syncedAt,
+		Source:   s.source,
+	}
+}
+
+// syncServerTime 从时间接口获取服务器时间并更新偏差
+// 接口返回 data.timestamp(10位秒级时间戳)，不需要签名
+func (c *MerchantClient) syncServerTime(ctx context.Context, config *MerchantConfig, b *backend) error {
+	httpClient, err := b.httpClient(config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.timeoutFor(serverTimePath))
+	defer cancel()
+
+	resp, err := httpClient.R().
+		SetContext(ctx).
+		Get(config.BaseURL + serverTimePath)
+	if err != nil {
+		return err
+	}
+
+	data, err := resp.ToString()
+	if err != nil {
+		return err
+	}
+
+	timestamp := gjson.Get(data, "data.timestamp").Int()
+	if timestamp <= 0 {
+		// 接口不可用时退回使用Date头
+		if b.clock.observeHeader(resp.Header) {
+			return nil
+		}
+		return errBadResponse
+	}
+
+	b.clock.update(time.Unix(timestamp, 0), "时间接口")
+	return nil
+}
+
+// SkewState 获取指定商户系统的时钟偏差
+func (c *MerchantClient) SkewState(profile string) SkewState {
+	return c.backend(profile).clock.state()
+}
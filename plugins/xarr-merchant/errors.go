@@ -0,0 +1,100 @@
+package xarrmerchant
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// 错误分类，可通过 errors.Is 判断
+var (
+	// ErrNotConfigured 尚未配置商户API
+	ErrNotConfigured = errors.New("未配置商户API，请联系管理员设置")
+	// ErrNotBound 用户未绑定商户账号
+	ErrNotBound = errors.New("未绑定商户账号")
+	// ErrSignature 签名校验失败
+	ErrSignature = errors.New("签名校验失败")
+	// ErrResponseSignature 响应签名缺失或不匹配
+	ErrResponseSignature = errors.New("响应签名校验失败")
+	// ErrTimestamp 时间戳过期或无效
+	ErrTimestamp = errors.New("请求时间戳无效")
+	// ErrAccountDisabled 商户账号已被禁用
+	ErrAccountDisabled = errors.New("商户账号已被禁用")
+	// ErrOrderNotFound 订单不存在
+	ErrOrderNotFound = errors.New("订单不存在")
+	// ErrUpstream 商户系统网络异常或服务端错误
+	ErrUpstream = errors.New("商户系统连接失败")
+	// ErrCircuitOpen 熔断期间直接返回的错误
+	ErrCircuitOpen = errors.New("商户系统暂时不可用，请稍后再试")
+
+	// errBadResponse 响应不是合法的JSON(通常是网关错误页)
+	errBadResponse = errors.New("商户系统响应格式错误")
+)
+
+// errorKeywords 根据服务端message归类业务错误
+// XArrPay 各接口的错误code不统一，按提示文字匹配
+// 只匹配完整短语，避免 "assigned" 命中 sign、"渠道已被禁用" 命中账号禁用等误判
+var errorKeywords = []struct {
+	kind     error
+	keywords []string
+}{
+	{ErrNotBound, []string{"未绑定", "绑定信息不存在", "not bound", "not bind"}},
+	{ErrSignature, []string{"签名错误", "签名校验失败", "签名验证失败", "签名无效", "invalid sign", "sign error", "signature error"}},
+	{ErrTimestamp, []string{"时间戳无效", "时间戳错误", "时间戳过期", "时间戳已过期", "请求已过期", "invalid timestamp", "timestamp expired"}},
+	{ErrAccountDisabled, []string{"账号已被禁用", "账号已禁用", "账户已被禁用", "账户已禁用", "用户已被禁用", "用户已禁用", "账号已冻结", "账户已冻结", "account disabled", "user disabled"}},
+	{ErrOrderNotFound, []string{"订单不存在", "未找到订单", "order not found"}},
+}
+
+// APIError XArrPay 接口返回的业务错误
+type APIError struct {
+	Path     string // 请求路径
+	Code     int64  // 接口返回的code
+	Message  string // 接口返回的message
+	Redirect string // 接口返回的redirect
+
+	HTTPStatus int   // HTTP状态码
+	Kind       error // 错误分类，未识别时为nil
+}
+
+// newAPIError 创建业务错误并归类
+func newAPIError(path string, code int64, message, redirect string, httpStatus int) *APIError {
+	return &APIError{
+		Path:       path,
+		Code:       code,
+		Message:    message,
+		Redirect:   redirect,
+		HTTPStatus: httpStatus,
+		Kind:       classifyMessage(message, httpStatus),
+	}
+}
+
+// Error 实现error接口，直接返回服务端提示信息
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return "商户系统返回错误(code=" + strconv.FormatInt(e.Code, 10) + ")"
+	}
+	return e.Message
+}
+
+// Unwrap 返回错误分类，使 errors.Is(err, ErrNotBound) 等判断生效
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// classifyMessage 根据提示信息和HTTP状态码归类
+func classifyMessage(message string, httpStatus int) error {
+	lower := strings.ToLower(message)
+	for _, item := range errorKeywords {
+		for _, keyword := range item.keywords {
+			if strings.Contains(lower, keyword) {
+				return item.kind
+			}
+		}
+	}
+
+	if httpStatus >= 500 {
+		return ErrUpstream
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package xarrmerchant
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -9,50 +11,68 @@ import (
 
 	"github.com/xiaoyi510/xbot"
 	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/logger"
 	"github.com/xiaoyi510/xbot/message"
 )
 
 // registerAdminHandlers 注册超管命令处理器
 func registerAdminHandlers(engine *xbot.Engine) {
 	// 超管命令 - 设置商户系统配置
-	engine.OnRegex(`^/设置商户系统\s+(\S+)\s+(\S+)`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
+	engine.OnRegex(`^/设置商户系统\s+(\S+)\s+(\S+)(?:\s+(\S+))?`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
 		if !ctx.IsSuperUser() {
 			ctx.Reply("❌ 权限不足，仅超级管理员可操作")
 			return
 		}
 
 		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 3 {
-			ctx.Reply("❌ 参数不完整\n用法: /设置商户系统 <API地址> <Secret密钥>")
+			ctx.Reply("❌ 参数不完整\n用法: /设置商户系统 <API地址> <Secret密钥> [签名算法]")
 			return
 		}
 
 		baseURL := ctx.RegexResult.Groups[1]
 		secret := ctx.RegexResult.Groups[2]
 
+		var signType string
+		if len(ctx.RegexResult.Groups) > 3 && ctx.RegexResult.Groups[3] != "" {
+			var err error
+			signType, err = normalizeSignType(ctx.RegexResult.Groups[3])
+			if err != nil {
+				ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+				return
+			}
+		}
+
 		// 检查client初始化
 		if client == nil {
 			ctx.Reply("❌ 系统初始化失败")
 			return
 		}
 
-		// 创建新配置
-		config := &MerchantConfig{
-			BaseURL:       baseURL,
-			Secret:        secret,
-			AllowedGroups: []int64{}, // 初始化为空，需要单独设置
+		// 固定配置默认商户系统，已有配置时保留其余设置，仅更新API地址和密钥
+		config, err := client.GetProfileConfig(DefaultProfile)
+		if err != nil {
+			config = &MerchantConfig{}
+		}
+		config.BaseURL = baseURL
+		config.Secret = secret
+		if signType != "" {
+			config.SignType = signType
 		}
 
 		// 保存配置
-		if err := client.SaveConfig(config); err != nil {
+		if err := client.SaveProfileConfig(DefaultProfile, config); err != nil {
 			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
 			return
 		}
 
-		msg := fmt.Sprintf("✅ 商户系统配置成功\n\n"+
+		msg := fmt.Sprintf("✅ 商户系统 [%s] 配置成功\n\n"+
 			"API地址: %s\n"+
-			"密钥: %s",
+			"密钥: %s\n"+
+			"签名算法: %s",
+			DefaultProfile,
 			baseURL,
-			maskSecret(secret))
+			maskSecret(secret),
+			formatSignType(config.SignType))
 
 		ctx.Reply(msg)
 	})
@@ -84,18 +104,8 @@ func registerAdminHandlers(engine *xbot.Engine) {
 			return
 		}
 
-		// 获取现有配置
-		config, err := client.GetConfig()
-		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 获取配置失败: %s\n请先使用 /设置商户系统 配置API", err.Error()))
-			return
-		}
-
-		// 更新允许的群聊
-		config.AllowedGroups = groups
-
-		// 保存配置
-		if err := client.SaveConfig(config); err != nil {
+		// 白名单为全局设置，所有商户系统共用
+		if err := client.SaveAllowedGroups(groups); err != nil {
 			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
 			return
 		}
@@ -109,8 +119,268 @@ func registerAdminHandlers(engine *xbot.Engine) {
 		ctx.Reply(msg)
 	})
 
+	// 超管命令 - 设置签名算法
+	engine.OnRegex(`^/设置商户签名\s+(\S+)(?:\s+(\S+))?`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /设置商户签名 <md5|sha256|hmac-sha256> [名称]")
+			return
+		}
+
+		signType, err := normalizeSignType(ctx.RegexResult.Groups[1])
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+			return
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		name, config, ok := settingsProfile(ctx, regexGroup(ctx, 2))
+		if !ok {
+			return
+		}
+
+		config.SignType = signType
+
+		if err := client.SaveProfileConfig(name, config); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 商户系统 [%s] 签名算法已设置为 %s\n请确认商户系统使用相同的签名算法", name, formatSignType(signType)))
+	})
+
+	// 超管命令 - 设置响应验签
+	engine.OnRegex(`^/设置商户验签\s+(开启|关闭)(?:\s+(\S+))?`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /设置商户验签 <开启|关闭> [名称]")
+			return
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		name, config, ok := settingsProfile(ctx, regexGroup(ctx, 2))
+		if !ok {
+			return
+		}
+
+		config.VerifyResponse = ctx.RegexResult.Groups[1] == "开启"
+
+		if err := client.SaveProfileConfig(name, config); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		if config.VerifyResponse {
+			ctx.Reply(fmt.Sprintf("✅ 商户系统 [%s] 已开启响应验签\n未签名或签名不匹配的响应将被拒绝，请确认商户系统已支持响应签名", name))
+		} else {
+			ctx.Reply(fmt.Sprintf("✅ 商户系统 [%s] 已关闭响应验签", name))
+		}
+	})
+
+	// 超管命令 - 设置请求随机串
+	engine.OnRegex(`^/设置商户防重放\s+(开启|关闭)(?:\s+(\S+))?`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /设置商户防重放 <开启|关闭> [名称]")
+			return
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		name, config, ok := settingsProfile(ctx, regexGroup(ctx, 2))
+		if !ok {
+			return
+		}
+
+		config.EnableNonce = ctx.RegexResult.Groups[1] == "开启"
+
+		if err := client.SaveProfileConfig(name, config); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		if config.EnableNonce {
+			ctx.Reply(fmt.Sprintf("✅ 商户系统 [%s] 已开启防重放\n每个请求将附加随机串nonce参与签名，请确认商户系统已支持nonce校验", name))
+		} else {
+			ctx.Reply(fmt.Sprintf("✅ 商户系统 [%s] 已关闭防重放", name))
+		}
+	})
+
+	// 超管命令 - 设置网络(代理、证书、TLS)
+	engine.OnRegex(`^/设置商户网络\s+(代理|根证书|客户端证书|TLS版本|跳过证书验证)\s+(\S+)(?:\s+(\S+))?(?:\s+(\S+))?`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 3 {
+			ctx.Reply("❌ 参数不完整\n" +
+				"用法:\n" +
+				"/设置商户网络 代理 <http://host:port|socks5://host:port|关闭> [名称]\n" +
+				"/设置商户网络 根证书 <PEM文件路径|关闭> [名称]\n" +
+				"/设置商户网络 客户端证书 <证书路径> <私钥路径> [名称]\n" +
+				"/设置商户网络 客户端证书 关闭 [名称]\n" +
+				"/设置商户网络 TLS版本 <1.0|1.1|1.2|1.3|默认> [名称]\n" +
+				"/设置商户网络 跳过证书验证 <开启|关闭> [名称]")
+			return
+		}
+
+		item := ctx.RegexResult.Groups[1]
+		value := ctx.RegexResult.Groups[2]
+		disable := value == "关闭" || value == "默认"
+
+		// 只有设置客户端证书需要两个值，其余情况值后面的参数为商户系统名称
+		var extra, profile string
+		if item == "客户端证书" && !disable {
+			extra = regexGroup(ctx, 3)
+			profile = regexGroup(ctx, 4)
+		} else {
+			if regexGroup(ctx, 4) != "" {
+				ctx.Reply(fmt.Sprintf("❌ 参数过多\n用法: /设置商户网络 %s <值> [名称]", item))
+				return
+			}
+			profile = regexGroup(ctx, 3)
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		name, config, ok := settingsProfile(ctx, profile)
+		if !ok {
+			return
+		}
+
+		var warning string
+
+		switch item {
+		case "代理":
+			if disable {
+				config.Proxy = ""
+			} else {
+				if _, err := parseProxyURL(value); err != nil {
+					ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+					return
+				}
+				config.Proxy = value
+			}
+		case "根证书":
+			if disable {
+				config.CACertFile = ""
+			} else {
+				config.CACertFile = value
+			}
+		case "客户端证书":
+			if disable {
+				config.ClientCertFile = ""
+				config.ClientKeyFile = ""
+			} else {
+				if extra == "" {
+					ctx.Reply("❌ 请同时提供证书和私钥路径\n用法: /设置商户网络 客户端证书 <证书路径> <私钥路径> [名称]")
+					return
+				}
+				config.ClientCertFile = value
+				config.ClientKeyFile = extra
+			}
+		case "TLS版本":
+			if disable {
+				config.TLSMinVersion = ""
+			} else {
+				if _, ok := tlsVersions[value]; !ok {
+					ctx.Reply("❌ 不支持的TLS版本，可选: 1.0, 1.1, 1.2, 1.3, 默认")
+					return
+				}
+				config.TLSMinVersion = value
+			}
+		case "跳过证书验证":
+			if value != "开启" && value != "关闭" {
+				ctx.Reply("❌ 参数错误\n用法: /设置商户网络 跳过证书验证 <开启|关闭> [名称]")
+				return
+			}
+			config.InsecureSkipVerify = value == "开启"
+			if config.InsecureSkipVerify {
+				warning = "\n\n⚠️ 警告: 跳过证书验证后无法识别伪造的服务器，API密钥和商户数据可能被窃取，仅建议在调试时临时使用"
+			}
+		}
+
+		// 保存前先尝试构建客户端，确保证书等文件可用
+		if _, err := buildHTTPClient(config); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 设置未保存: %s", err.Error()))
+			return
+		}
+
+		if err := client.SaveProfileConfig(name, config); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 商户系统 [%s] 网络设置已更新，下次请求时生效\n\n%s%s", name, formatNetworkConfig(config), warning))
+	})
+
+	// 超管命令 - 设置请求超时
+	engine.OnRegex(`^/设置商户超时\s+(\d+)(?:\s+(\S+))?(?:\s+(\S+))?`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /设置商户超时 <秒> [接口路径] [名称]\n示例: /设置商户超时 15 /api/system-api/user/pay-stat")
+			return
+		}
+
+		seconds, err := strconv.Atoi(ctx.RegexResult.Groups[1])
+		if err != nil || seconds > maxTimeoutSeconds {
+			ctx.Reply(fmt.Sprintf("❌ 超时时间无效，范围 0-%d 秒", maxTimeoutSeconds))
+			return
+		}
+
+		// 接口路径以/开头，否则视为商户系统名称
+		path, profile := regexGroup(ctx, 2), regexGroup(ctx, 3)
+		if path != "" && !strings.HasPrefix(path, "/") {
+			if profile != "" {
+				ctx.Reply("❌ 接口路径需以/开头\n用法: /设置商户超时 <秒> [接口路径] [名称]")
+				return
+			}
+			path, profile = "", path
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		name, config, ok := settingsProfile(ctx, profile)
+		if !ok {
+			return
+		}
+
+		var msg string
+		if path == "" {
+			config.Timeout = seconds
+			msg = fmt.Sprintf("✅ 商户系统 [%s] 全局请求超时已设置为 %s", name, formatTimeout(seconds))
+		} else {
+			if config.RequestTimeouts == nil {
+				config.RequestTimeouts = map[string]int{}
+			}
+			// 设置为0表示移除该接口的单独设置
+			if seconds == 0 {
+				delete(config.RequestTimeouts, path)
+			} else {
+				config.RequestTimeouts[path] = seconds
+			}
+			msg = fmt.Sprintf("✅ 商户系统 [%s] 接口 %s 请求超时已设置为 %s", name, path, formatTimeout(seconds))
+		}
+
+		if err := client.SaveProfileConfig(name, config); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		ctx.Reply(msg)
+	})
+
 	// 超管命令 - 查看配置
-	engine.OnCommand("查看商户配置", xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
+	engine.OnRegex(`^/查看商户配置(?:\s+(\S+))?`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
 		if !ctx.IsSuperUser() {
 			ctx.Reply("❌ 权限不足，仅超级管理员可操作")
 			return
@@ -121,23 +391,331 @@ func registerAdminHandlers(engine *xbot.Engine) {
 			return
 		}
 
-		config, err := client.GetConfig()
+		var name string
+		if ctx.RegexResult != nil {
+			name = regexGroup(ctx, 1)
+		}
+		profile, config, ok := settingsProfile(ctx, name)
+		if !ok {
+			return
+		}
+
+		// 群聊白名单为全局设置，不随当前商户系统变化
+		allowedGroups := client.AllowedGroups()
+
+		msg := fmt.Sprintf("⚙️ 商户配置 [%s]\n\n"+
+			"API地址: %s\n"+
+			"密钥: %s\n"+
+			"签名算法: %s\n"+
+			"响应验签: %s\n"+
+			"防重放: %s\n"+
+			"允许的群聊(全局): %s (%d个)\n"+
+			"请求超时: %s\n"+
+			"接口超时: %s\n"+
+			"%s\n"+
+			"系统状态: %s\n"+
+			"时钟偏差: %s\n"+
+			"到账回调: %s\n"+
+			"主动推送: %s",
+			profile,
+			config.BaseURL,
+			maskSecret(config.Secret),
+			formatSignType(config.SignType),
+			formatSwitch(config.VerifyResponse),
+			formatSwitch(config.EnableNonce),
+			formatGroupIDs(allowedGroups),
+			len(allowedGroups),
+			formatTimeout(config.Timeout),
+			formatRequestTimeouts(config.RequestTimeouts),
+			formatNetworkConfig(config),
+			formatBreakerState(client.BreakerState(profile)),
+			formatSkewState(client.SkewState(profile)),
+			formatWebhookState(profile),
+			formatPushState())
+
+		ctx.Reply(msg)
+	})
+}
+
+// registerProfileHandlers 注册多商户系统管理命令处理器
+// 设置命令通过可选的名称参数指定商户系统，不指定时修改default，不受 /切换商户系统 影响
+func registerProfileHandlers(engine *xbot.Engine) {
+	// 超管命令 - 添加或更新商户系统
+	engine.OnRegex(`^/添加商户系统\s+(\S+)\s+(\S+)\s+(\S+)(?:\s+(\S+))?`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 4 {
+			ctx.Reply("❌ 参数不完整\n用法: /添加商户系统 <名称> <API地址> <Secret密钥> [签名算法]")
+			return
+		}
+
+		name := ctx.RegexResult.Groups[1]
+		if err := validateProfileName(name); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+			return
+		}
+
+		var signType string
+		if len(ctx.RegexResult.Groups) > 4 && ctx.RegexResult.Groups[4] != "" {
+			var err error
+			signType, err = normalizeSignType(ctx.RegexResult.Groups[4])
+			if err != nil {
+				ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+				return
+			}
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		// 已存在时保留其余设置，仅更新API地址、密钥和签名算法
+		config, err := client.GetProfileConfig(name)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 获取配置失败: %s", err.Error()))
+			config = &MerchantConfig{}
+		}
+		config.BaseURL = ctx.RegexResult.Groups[2]
+		config.Secret = ctx.RegexResult.Groups[3]
+		if signType != "" {
+			config.SignType = signType
+		}
+
+		if err := client.SaveProfileConfig(name, config); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
 			return
 		}
 
-		msg := fmt.Sprintf("⚙️ 商户配置\n\n"+
+		msg := fmt.Sprintf("✅ 商户系统 [%s] 已保存\n\n"+
 			"API地址: %s\n"+
 			"密钥: %s\n"+
-			"允许的群聊: %s (%d个)",
+			"签名算法: %s",
+			name,
 			config.BaseURL,
 			maskSecret(config.Secret),
-			formatGroupIDs(config.AllowedGroups),
-			len(config.AllowedGroups))
+			formatSignType(config.SignType))
 
 		ctx.Reply(msg)
 	})
+
+	// 超管命令 - 商户系统列表
+	engine.OnCommand("商户系统列表", xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		names, err := client.ListProfiles()
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 获取失败: %s", err.Error()))
+			return
+		}
+
+		if len(names) == 0 {
+			ctx.Reply("📋 暂无商户系统\n请使用 /设置商户系统 或 /添加商户系统 配置")
+			return
+		}
+
+		index, err := client.GetIndex()
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 获取失败: %s", err.Error()))
+			return
+		}
+
+		var msg strings.Builder
+		msg.WriteString(fmt.Sprintf("📋 商户系统列表 (共%d个)\n\n", len(names)))
+
+		for i, name := range names {
+			activeText := ""
+			if name == index.Active {
+				activeText = " ⭐默认"
+			}
+
+			baseURL := "配置读取失败"
+			if config, err := client.GetProfileConfig(name); err == nil {
+				baseURL = config.BaseURL
+			}
+
+			msg.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, name, activeText))
+			msg.WriteString(fmt.Sprintf("   API地址: %s\n", baseURL))
+			msg.WriteString(fmt.Sprintf("   状态: %s\n", formatBreakerState(client.BreakerState(name))))
+			msg.WriteString(fmt.Sprintf("   分配群聊: %s\n", formatGroupIDs(assignedIDs(index.Groups, name))))
+			msg.WriteString(fmt.Sprintf("   分配用户: %s\n", formatGroupIDs(assignedIDs(index.Users, name))))
+			if i < len(names)-1 {
+				msg.WriteString("\n")
+			}
+		}
+
+		ctx.Reply(msg.String())
+	})
+
+	// 超管命令 - 删除商户系统
+	engine.OnRegex(`^/删除商户系统\s+(\S+)`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /删除商户系统 <名称>")
+			return
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		name := ctx.RegexResult.Groups[1]
+		if err := client.DeleteProfile(name); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 删除失败: %s", err.Error()))
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 商户系统 [%s] 已删除，相关的群聊和用户分配已一并移除", name))
+	})
+
+	// 超管命令 - 切换默认商户系统
+	engine.OnRegex(`^/切换商户系统\s+(\S+)`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /切换商户系统 <名称>")
+			return
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		name := ctx.RegexResult.Groups[1]
+		if err := client.SetActiveProfile(name); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 切换失败: %s", err.Error()))
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 默认商户系统已切换为 [%s]\n未单独分配的群聊和用户将使用该系统，设置类命令也将作用于该系统", name))
+	})
+
+	// 超管命令 - 为群聊或用户分配商户系统
+	engine.OnRegex(`^/分配商户系统\s+(群|用户)\s+(\d+)\s+(\S+)`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 4 {
+			ctx.Reply("❌ 参数不完整\n用法: /分配商户系统 <群|用户> <号码> <名称|取消>")
+			return
+		}
+
+		target := ctx.RegexResult.Groups[1]
+		id, err := strconv.ParseInt(ctx.RegexResult.Groups[2], 10, 64)
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 无效的号码: %s", ctx.RegexResult.Groups[2]))
+			return
+		}
+
+		name := ctx.RegexResult.Groups[3]
+		if name == "取消" {
+			name = ""
+		}
+
+		if client == nil {
+			ctx.Reply("❌ 系统初始化失败")
+			return
+		}
+
+		if target == "群" {
+			err = client.AssignGroup(id, name)
+		} else {
+			err = client.AssignUser(id, name)
+		}
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 分配失败: %s", err.Error()))
+			return
+		}
+
+		if name == "" {
+			ctx.Reply(fmt.Sprintf("✅ 已取消%s %d 的商户系统分配，将使用默认商户系统", target, id))
+			return
+		}
+
+		msg := fmt.Sprintf("✅ 已将%s %d 分配到商户系统 [%s]", target, id, name)
+		if target == "群" && !client.IsGroupAllowed(id) {
+			msg += "\n\n⚠️ 该群不在群聊白名单中，请使用 /设置商户群聊 开通后才能使用商户命令"
+		}
+		ctx.Reply(msg)
+	})
+}
+
+// groupIDOf 获取消息所在群号，私聊返回0
+func groupIDOf(ctx *xbot.Context) int64 {
+	if evt, ok := ctx.Event.(*event.GroupMessageEvent); ok {
+		return evt.GroupID
+	}
+	return 0
+}
+
+// regexGroup 获取可选的正则分组，未匹配时返回空串
+func regexGroup(ctx *xbot.Context, i int) string {
+	if len(ctx.RegexResult.Groups) > i {
+		return ctx.RegexResult.Groups[i]
+	}
+	return ""
+}
+
+// settingsProfile 读取设置命令要修改的商户系统配置，未指定名称时为default
+// 不跟随 /切换商户系统，避免切换后误改其他商户系统；读取失败时直接回复
+func settingsProfile(ctx *xbot.Context, name string) (string, *MerchantConfig, bool) {
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	config, err := client.GetProfileConfig(name)
+	if err != nil {
+		if name == DefaultProfile {
+			ctx.Reply(fmt.Sprintf("❌ 获取配置失败: %s\n请先使用 /设置商户系统 配置API", err.Error()))
+		} else {
+			ctx.Reply(fmt.Sprintf("❌ 获取配置失败: %s\n请先使用 /添加商户系统 添加，或 /商户系统列表 查看", err.Error()))
+		}
+		return "", nil, false
+	}
+
+	return name, config, true
+}
+
+// sessionKey 会话key(群号:QQ号)，用于翻页、确认等需要记住上下文的交互
+// 不同聊天中的会话互不影响，私聊群号为0
+func sessionKey(ctx *xbot.Context) string {
+	return fmt.Sprintf("%d:%d", groupIDOf(ctx), ctx.GetUserID())
+}
+
+// requestContext 创建API请求使用的context
+// 按群聊和用户分配选择商户系统，机器人退出时自动取消
+func requestContext(ctx *xbot.Context) context.Context {
+	return WithProfile(pluginCtx, client.ResolveProfile(ctx.GetUserID(), groupIDOf(ctx)))
+}
+
+// replyAPIError 根据错误类型回复用户
+// action 为操作名称，如"查询"、"绑定"
+func replyAPIError(ctx *xbot.Context, action string, err error) {
+	switch {
+	case errors.Is(err, ErrNotConfigured):
+		ctx.Reply("❌ 商户系统尚未配置，请联系管理员设置")
+	case errors.Is(err, ErrNotBound):
+		ctx.Reply("❌ 您还未绑定商户账号\n" +
+			"请在商户后台获取绑定ticket后发送:\n" +
+			"/绑定 <ticket>")
+	case errors.Is(err, ErrAccountDisabled):
+		ctx.Reply("❌ 您的商户账号已被禁用，请联系客服处理")
+	case errors.Is(err, ErrResponseSignature):
+		logger.Warn(fmt.Sprintf("商户系统响应验签失败: %s", err.Error()))
+		ctx.Reply(fmt.Sprintf("❌ %s失败: 商户系统响应签名校验未通过\n请联系管理员检查密钥或验签设置", action))
+	case errors.Is(err, ErrSignature):
+		ctx.Reply(fmt.Sprintf("❌ %s失败: 签名校验未通过\n请联系管理员检查商户系统密钥配置", action))
+	case errors.Is(err, ErrTimestamp):
+		ctx.Reply(fmt.Sprintf("❌ %s失败: 请求时间戳无效\n请联系管理员检查机器人服务器时间", action))
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrUpstream):
+		logger.Error(fmt.Sprintf("商户系统请求失败: %s", err.Error()))
+		ctx.Reply("⚠️ 商户系统暂时不可用，请稍后再试")
+	case errors.Is(err, context.Canceled):
+		ctx.Reply(fmt.Sprintf("❌ %s已取消: 机器人正在退出", action))
+	default:
+		msg := fmt.Sprintf("❌ %s失败: %s", action, err.Error())
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Redirect != "" {
+			msg += "\n🔗 " + apiErr.Redirect
+		}
+		ctx.Reply(msg)
+	}
 }
 
 // registerUserHandlers 注册用户命令处理器
@@ -152,12 +730,25 @@ func registerUserHandlers(engine *xbot.Engine) {
 		ticket := ctx.RegexResult.Groups[1]
 		openID := strconv.FormatInt(ctx.GetUserID(), 10)
 
-		err := client.BindUser(ticket, openID)
+		err := client.BindUserContext(requestContext(ctx), ticket, openID)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 绑定失败: %s", err.Error()))
+			replyAPIError(ctx, "绑定", err)
 			return
 		}
 
+		// 记录绑定用户，用于套餐到期提醒等后台通知
+		// 已订阅时保留原商户系统，避免与已保存的UID和其他巡检功能不一致
+		userID := ctx.GetUserID()
+		profile := client.ResolveProfile(userID, groupIDOf(ctx))
+		err = updateSubscription(userID, func(sub *Subscription) {
+			if sub.Profile == "" {
+				sub.Profile = profile
+			}
+		})
+		if err != nil {
+			logger.Warn(fmt.Sprintf("记录用户 %d 的绑定信息失败: %s", userID, err.Error()))
+		}
+
 		ctx.Reply("✅ 绑定成功!")
 	})
 
@@ -165,12 +756,17 @@ func registerUserHandlers(engine *xbot.Engine) {
 	engine.OnCommand("解绑").Handle(func(ctx *xbot.Context) {
 		openID := strconv.FormatInt(ctx.GetUserID(), 10)
 
-		err := client.UnbindUser(openID)
+		err := client.UnbindUserContext(requestContext(ctx), openID)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 解绑失败: %s", err.Error()))
+			replyAPIError(ctx, "解绑", err)
 			return
 		}
 
+		// 解绑后不再推送该用户的通知
+		if err := removeSubscription(ctx.GetUserID()); err != nil {
+			logger.Warn(fmt.Sprintf("删除用户 %d 的通知订阅失败: %s", ctx.GetUserID(), err.Error()))
+		}
+
 		ctx.Reply("✅ 解绑成功!")
 	})
 
@@ -178,9 +774,9 @@ func registerUserHandlers(engine *xbot.Engine) {
 	engine.OnCommandGroup([]string{"我的信息", "个人信息"}).Handle(func(ctx *xbot.Context) {
 		openID := strconv.FormatInt(ctx.GetUserID(), 10)
 
-		userInfo, err := client.GetUserInfo(openID)
+		userInfo, err := client.GetUserInfoContext(requestContext(ctx), openID)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 查询失败: %s", err.Error()))
+			replyAPIError(ctx, "查询", err)
 			return
 		}
 
@@ -219,9 +815,9 @@ func registerUserHandlers(engine *xbot.Engine) {
 	engine.OnCommandGroup([]string{"余额", "查询余额"}).Handle(func(ctx *xbot.Context) {
 		openID := strconv.FormatInt(ctx.GetUserID(), 10)
 
-		balance, err := client.GetUserBalance(openID)
+		balance, err := client.GetUserBalanceContext(requestContext(ctx), openID)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 查询失败: %s", err.Error()))
+			replyAPIError(ctx, "查询", err)
 			return
 		}
 
@@ -232,9 +828,9 @@ func registerUserHandlers(engine *xbot.Engine) {
 	engine.OnCommandGroup([]string{"套餐信息", "我的套餐"}).Handle(func(ctx *xbot.Context) {
 		openID := strconv.FormatInt(ctx.GetUserID(), 10)
 
-		mealInfo, err := client.GetUserMealInfo(openID)
+		mealInfo, err := client.GetUserMealInfoContext(requestContext(ctx), openID)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 查询失败: %s", err.Error()))
+			replyAPIError(ctx, "查询", err)
 			return
 		}
 
@@ -251,30 +847,6 @@ func registerUserHandlers(engine *xbot.Engine) {
 			expireStatus = "正常"
 		}
 
-		// 处理通道账号数
-		var channelCountText string
-		if mealInfo.ChannelAccountCount == -1 {
-			channelCountText = "不限制"
-		} else {
-			channelCountText = fmt.Sprintf("%d", mealInfo.ChannelAccountCount)
-		}
-
-		// 处理日限额
-		var dayLimitText string
-		if mealInfo.DayLimit == -1 {
-			dayLimitText = "不限制"
-		} else {
-			dayLimitText = "¥" + formatAmount(mealInfo.DayLimit)
-		}
-
-		// 处理月限额
-		var monthLimitText string
-		if mealInfo.MonthLimit == -1 {
-			monthLimitText = "不限制"
-		} else {
-			monthLimitText = "¥" + formatAmount(mealInfo.MonthLimit)
-		}
-
 		msg := fmt.Sprintf("📦 套餐信息\n\n"+
 			"套餐名称: %s\n"+
 			"到期时间: %s\n"+
@@ -282,14 +854,14 @@ func registerUserHandlers(engine *xbot.Engine) {
 			"通道账号数: %s\n"+
 			"日限额: %s\n"+
 			"月限额: %s\n"+
-			"费率: %.2f%%",
+			"费率: %s",
 			mealInfo.MealName,
 			expireTimeText,
 			expireStatus,
-			channelCountText,
-			dayLimitText,
-			monthLimitText,
-			float64(mealInfo.Rate)/100)
+			formatLimitCount(mealInfo.ChannelAccountCount),
+			formatLimitAmount(mealInfo.DayLimit),
+			formatLimitAmount(mealInfo.MonthLimit),
+			formatRate(mealInfo.Rate))
 
 		ctx.Reply(msg)
 	})
@@ -298,9 +870,9 @@ func registerUserHandlers(engine *xbot.Engine) {
 	engine.OnCommandGroup([]string{"今日统计", "今日"}).Handle(func(ctx *xbot.Context) {
 		openID := strconv.FormatInt(ctx.GetUserID(), 10)
 
-		stat, err := client.GetUserPayStat(openID)
+		stat, err := client.GetUserPayStatContext(requestContext(ctx), openID)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 查询失败: %s", err.Error()))
+			replyAPIError(ctx, "查询", err)
 			return
 		}
 
@@ -319,9 +891,9 @@ func registerUserHandlers(engine *xbot.Engine) {
 	engine.OnCommandGroup([]string{"统计", "支付统计"}).Handle(func(ctx *xbot.Context) {
 		openID := strconv.FormatInt(ctx.GetUserID(), 10)
 
-		stat, err := client.GetUserPayStat(openID)
+		stat, err := client.GetUserPayStatContext(requestContext(ctx), openID)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 查询失败: %s", err.Error()))
+			replyAPIError(ctx, "查询", err)
 			return
 		}
 
@@ -350,9 +922,9 @@ func registerUserHandlers(engine *xbot.Engine) {
 	engine.OnCommandGroup([]string{"渠道列表", "账户列表"}).Handle(func(ctx *xbot.Context) {
 		openID := strconv.FormatInt(ctx.GetUserID(), 10)
 
-		accounts, err := client.GetChannelAccountList(openID)
+		accounts, err := client.GetChannelAccountListContext(requestContext(ctx), openID)
 		if err != nil {
-			ctx.Reply(fmt.Sprintf("❌ 查询失败: %s", err.Error()))
+			replyAPIError(ctx, "查询", err)
 			return
 		}
 
@@ -367,12 +939,12 @@ func registerUserHandlers(engine *xbot.Engine) {
 
 		for i, acc := range accounts {
 			statusText := "禁用"
-			if acc.Status == 1 {
+			if acc.Status == ChannelStatusEnabled {
 				statusText = "启用"
 			}
 
 			onlineText := "离线"
-			if acc.Online == 1 {
+			if acc.Online == ChannelOnline {
 				onlineText = "在线"
 			}
 
@@ -384,7 +956,7 @@ func registerUserHandlers(engine *xbot.Engine) {
 				accountName = maskAccountName(acc.Name)
 			}
 
-			msg.WriteString(fmt.Sprintf("%d. %s\n", i+1, accountName))
+			msg.WriteString(fmt.Sprintf("%d. %s (ID: %d)\n", i+1, accountName, acc.ID))
 			msg.WriteString(fmt.Sprintf("   支付方式: %s\n", acc.PayTypeName))
 			msg.WriteString(fmt.Sprintf("   状态: %s | %s\n", statusText, onlineText))
 			msg.WriteString(fmt.Sprintf("   今日: ¥%s / ¥%s\n",
@@ -410,23 +982,60 @@ func registerUserHandlers(engine *xbot.Engine) {
 /解绑 - 解绑商户账号
 /我的信息 - 查看个人信息
 /余额 - 查看账户余额
+/流水 [页码] [日期=...] - 查看资金流水
+/充值 <金额> [支付方式] - 余额充值
 
 📦 套餐相关
 /套餐信息 - 查看套餐详情
+/套餐列表 - 查看可购买的套餐
+/购买套餐 <套餐ID> - 使用余额购买套餐
+/续费 - 使用余额续费当前套餐
 
 📊 统计查询
 /今日统计 - 查看今日数据
 /统计 - 查看完整统计
-/渠道列表 - 查看渠道账户`
+/渠道列表 - 查看渠道账户
+/渠道详情 <ID> - 查看渠道详情
+/启用渠道 <序号|ID12> - 启用渠道账户(仅私聊)
+/禁用渠道 <序号|ID12> - 禁用渠道账户(仅私聊)
+/设置渠道限额 <ID> <金额> - 修改渠道单日限额(仅私聊)
+
+🧾 订单管理
+/查单 <订单号> - 查询订单详情
+/订单列表 [页码] [筛选条件] - 查看最近订单
+/补单 <订单号> - 重发支付通知
+/退款 <订单号> [金额] - 发起退款(仅私聊)
+/上一页 /下一页 - 列表翻页
+
+💳 收款
+/收款 <金额> [备注] [支付方式] - 创建收款二维码
+
+🔔 通知
+/到账通知 <开启|关闭> - 私聊或本群推送到账通知
+/渠道告警 <开启|关闭> - 渠道离线/禁用私聊告警
+/额度告警 [开启|关闭|阈值] - 渠道和套餐额度预警
+/续费提醒 [开启|关闭|天数] - 套餐到期提醒`
 
 		// 超管显示额外命令
 		if isSuperUser {
 			msg += `
 
 ⚙️ 超管命令
-/设置商户系统 <API地址> <Secret> - 设置系统配置
+/设置商户系统 <API地址> <Secret> [签名算法] - 配置default商户系统
+/设置商户签名 <md5|sha256|hmac-sha256> [名称] - 设置签名算法
+/设置商户验签 <开启|关闭> [名称] - 设置响应验签
+/设置商户防重放 <开启|关闭> [名称] - 设置请求随机串
 /设置商户群聊 <群号1,群号2,...> - 设置允许的群聊
-/查看商户配置 - 查看当前配置`
+/设置商户超时 <秒> [接口路径] [名称] - 设置请求超时
+/设置商户回调 <监听地址|关闭> - 设置到账回调服务
+/设置商户推送 <OneBot HTTP地址|关闭> [access_token] - 设置主动推送接口
+/设置商户网络 <代理|根证书|客户端证书|TLS版本|跳过证书验证> <值> [名称] - 设置网络
+/查看商户配置 [名称] - 查看商户系统配置
+/添加商户系统 <名称> <API地址> <Secret> [签名算法] - 添加商户系统
+/商户系统列表 - 查看所有商户系统
+/删除商户系统 <名称> - 删除商户系统
+/切换商户系统 <名称> - 切换默认商户系统
+/分配商户系统 <群|用户> <号码> <名称|取消> - 分配商户系统`
 		}
 
 		msg += `
@@ -451,7 +1060,13 @@ func registerGroupMessageHandler(engine *xbot.Engine) {
 					if matches := re.FindStringSubmatch(text); len(matches) > 1 {
 						cmd := matches[1]
 						// 商户相关命令列表
-						merchantCmds := []string{"绑定", "解绑", "我的信息", "个人信息", "余额", "查询余额", "套餐信息", "我的套餐", "今日统计", "今日", "统计", "支付统计", "渠道列表", "账户列表", "商户帮助", "商户菜单"}
+						merchantCmds := []string{
+							"绑定", "解绑", "我的信息", "个人信息", "余额", "查询余额", "套餐信息", "我的套餐",
+							"今日统计", "今日", "统计", "支付统计", "渠道列表", "账户列表", "商户帮助", "商户菜单",
+							"查单", "订单列表", "上一页", "下一页", "补单", "重发通知", "确认", "取消",
+							"收款", "流水", "资金流水", "充值", "套餐列表", "购买套餐", "续费",
+							"渠道详情", "启用渠道", "禁用渠道", "设置渠道限额", "到账通知", "渠道告警", "额度告警", "续费提醒",
+						}
 
 						isMerchantCmd := false
 						for _, mc := range merchantCmds {
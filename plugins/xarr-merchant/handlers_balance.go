@@ -0,0 +1,212 @@
+package xarrmerchant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xiaoyi510/xbot"
+)
+
+// balanceLogUsage 资金流水用法说明
+const balanceLogUsage = "用法: /流水 [页码] [日期=今天|昨天|近7天|本月|2006-01-02|2006-01-01~2006-01-31]\n" +
+	"示例: /流水 日期=本月"
+
+// parseBalanceLogFilter 解析资金流水参数
+func parseBalanceLogFilter(args string) (BalanceLogFilter, error) {
+	filter := BalanceLogFilter{Page: 1, PageSize: 10}
+
+	for _, field := range strings.Fields(args) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			// 不带key的数字视为页码，否则视为日期
+			if page, err := strconv.Atoi(field); err == nil && page >= 1 {
+				filter.Page = page
+				continue
+			}
+			key, value = "日期", field
+		}
+
+		if key != "日期" {
+			return filter, fmt.Errorf("无法识别的筛选条件: %s", key)
+		}
+
+		start, end, err := parseDateRange(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.StartTime, filter.EndTime = start, end
+	}
+
+	return filter, nil
+}
+
+// formatBalanceLogPage 格式化资金流水列表
+func formatBalanceLogPage(page *BalanceLogPage, isPrivate bool) string {
+	if len(page.List) == 0 {
+		return "📒 暂无资金流水"
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("📒 资金流水 (共%d条)\n\n", page.Total))
+
+	offset := (page.Page - 1) * page.PageSize
+	for i, log := range page.List {
+		amount := "¥" + formatSignedAmount(log.Amount)
+		balance := fmt.Sprintf("¥%s → ¥%s", formatAmount(log.Before), formatAmount(log.After))
+		if !isPrivate {
+			amount = maskAmount(absAmount(log.Amount))
+			balance = "****"
+		}
+
+		msg.WriteString(fmt.Sprintf("%d. %s %s\n", offset+i+1, log.TypeName, amount))
+		msg.WriteString(fmt.Sprintf("   余额: %s\n", balance))
+		if log.Remark != "" {
+			msg.WriteString(fmt.Sprintf("   备注: %s\n", log.Remark))
+		}
+		msg.WriteString(fmt.Sprintf("   时间: %s", formatTime(log.CreateTime)))
+		if i < len(page.List)-1 {
+			msg.WriteString("\n\n")
+		}
+	}
+
+	return msg.String()
+}
+
+// rechargeUsage 充值用法说明
+const rechargeUsage = "用法: /充值 <金额> [支付宝|微信|QQ]\n示例: /充值 100 支付宝"
+
+// parseRechargeArgs 解析充值参数: <金额> [支付方式]
+func parseRechargeArgs(args string) (int64, string, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return 0, "", errors.New("请提供充值金额")
+	}
+	if len(fields) > 2 {
+		return 0, "", errors.New("参数过多")
+	}
+
+	amount, err := parseAmount(fields[0])
+	if err != nil {
+		return 0, "", err
+	}
+	if amount <= 0 {
+		return 0, "", errors.New("充值金额必须大于0")
+	}
+	if amount > maxPaymentAmount {
+		return 0, "", fmt.Errorf("单笔充值金额不能超过 ¥%s", formatAmount(maxPaymentAmount))
+	}
+
+	var payType string
+	if len(fields) == 2 {
+		var ok bool
+		if payType, ok = payTypeAliases[fields[1]]; !ok {
+			return 0, "", fmt.Errorf("不支持的支付方式: %s", fields[1])
+		}
+	}
+
+	return amount, payType, nil
+}
+
+// absAmount 金额绝对值
+func absAmount(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+// registerBalanceHandlers 注册资金相关命令处理器
+func registerBalanceHandlers(engine *xbot.Engine) {
+	// 资金流水
+	engine.OnRegex(`^/(?:流水|资金流水)(?:\s+(.+))?$`).Handle(func(ctx *xbot.Context) {
+		var args string
+		if ctx.RegexResult != nil && len(ctx.RegexResult.Groups) > 1 {
+			args = ctx.RegexResult.Groups[1]
+		}
+
+		filter, err := parseBalanceLogFilter(args)
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ %s\n\n%s", err.Error(), balanceLogUsage))
+			return
+		}
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+		isPrivate := ctx.IsPrivateMessage()
+
+		load := func(page int) (string, int, error) {
+			f := filter
+			f.Page = page
+			result, err := client.ListBalanceLogsContext(reqCtx, openID, f)
+			if err != nil {
+				return "", 0, err
+			}
+			return formatBalanceLogPage(result, isPrivate), result.TotalPages(), nil
+		}
+
+		showPage(ctx, "查询", load, filter.Page)
+	})
+
+	// 余额充值
+	engine.OnRegex(`^/充值(?:\s+(.+))?$`).Handle(func(ctx *xbot.Context) {
+		var args string
+		if ctx.RegexResult != nil && len(ctx.RegexResult.Groups) > 1 {
+			args = ctx.RegexResult.Groups[1]
+		}
+
+		amount, payType, err := parseRechargeArgs(args)
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ %s\n%s", err.Error(), rechargeUsage))
+			return
+		}
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+
+		payment, err := client.CreateRechargeContext(reqCtx, openID, amount, payType)
+		if err != nil {
+			replyAPIError(ctx, "创建充值订单", err)
+			return
+		}
+
+		ctx.Reply(buildPaymentMessage("💰 充值订单已创建，支付后余额自动到账", payment))
+
+		// 等待到账后通知
+		watchPayment(reqCtx, "recharge:"+payment.TradeNo, paymentDeadline(payment.ExpireTime), func(pollCtx context.Context) bool {
+			recharge, err := client.GetRechargeContext(pollCtx, openID, payment.TradeNo)
+			if err != nil || recharge.Status == RechargeStatusUnpaid {
+				return false
+			}
+			if recharge.Status != RechargeStatusCredited {
+				ctx.Reply(fmt.Sprintf("❌ 充值订单 %s 已关闭", payment.OutTradeNo))
+				return true
+			}
+
+			// 接口未返回到账后余额时重新查询
+			balance := recharge.Balance
+			if balance <= 0 {
+				if balance, err = client.GetUserBalanceContext(pollCtx, openID); err != nil {
+					balance = -1
+				}
+			}
+
+			text := fmt.Sprintf("🎉 充值到账\n\n"+
+				"订单号: %s\n"+
+				"充值金额: ¥%s\n"+
+				"到账时间: %s",
+				payment.OutTradeNo,
+				formatAmount(recharge.Amount),
+				formatTime(recharge.PayTime))
+			if balance >= 0 {
+				text += fmt.Sprintf("\n当前余额: %s", displayAmount(balance, ctx.IsPrivateMessage()))
+			}
+			ctx.Reply(text)
+			return true
+		}, func() {
+			ctx.Reply(fmt.Sprintf("⌛ 充值订单 %s 未在有效期内支付，已停止等待", payment.OutTradeNo))
+		})
+	})
+}
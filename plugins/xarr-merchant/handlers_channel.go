@@ -0,0 +1,273 @@
+package xarrmerchant
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xiaoyi510/xbot"
+)
+
+// channelRef 渠道参数，/渠道列表 中的序号或渠道ID
+type channelRef struct {
+	N    int64
+	ByID bool
+}
+
+// parseChannelRef 解析渠道参数，带 ID 前缀(如 ID12)或 byID 为true时为渠道ID，否则为序号
+// 纯数字不会在序号和ID之间自动切换，避免序号超出范围时误匹配到ID相同的其他渠道
+func parseChannelRef(ref string, byID bool) (channelRef, error) {
+	ref = strings.TrimSpace(ref)
+
+	if upper := strings.ToUpper(ref); strings.HasPrefix(upper, "ID") {
+		byID = true
+		ref = strings.TrimLeft(ref[2:], ":：=")
+	}
+
+	n, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil || n <= 0 {
+		if byID {
+			return channelRef{}, fmt.Errorf("无效的渠道ID: %s", ref)
+		}
+		return channelRef{}, fmt.Errorf("无效的序号: %s", ref)
+	}
+
+	return channelRef{N: n, ByID: byID}, nil
+}
+
+// String 描述匹配方式，用于确认信息中区分序号和ID
+func (r channelRef) String() string {
+	if r.ByID {
+		return fmt.Sprintf("渠道ID %d", r.N)
+	}
+	return fmt.Sprintf("/渠道列表 第%d个", r.N)
+}
+
+// resolveChannelAccount 按序号或渠道ID查找渠道账户
+func resolveChannelAccount(accounts []ChannelAccount, ref channelRef) (*ChannelAccount, error) {
+	if !ref.ByID {
+		if ref.N > int64(len(accounts)) {
+			return nil, fmt.Errorf("序号 %d 超出范围，共 %d 个渠道账户，按ID指定请写作 ID%d", ref.N, len(accounts), ref.N)
+		}
+		return &accounts[ref.N-1], nil
+	}
+
+	for i := range accounts {
+		if accounts[i].ID == ref.N {
+			return &accounts[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("未找到渠道账户: ID %d", ref.N)
+}
+
+// findChannelAccount 解析渠道参数并查询渠道列表查找，失败时直接回复
+func findChannelAccount(ctx *xbot.Context, openID, arg string, byID bool) (*ChannelAccount, channelRef, bool) {
+	ref, err := parseChannelRef(arg, byID)
+	if err != nil {
+		ctx.Reply(fmt.Sprintf("❌ %s\n发送 /渠道列表 查看序号和ID", err.Error()))
+		return nil, ref, false
+	}
+
+	accounts, err := client.GetChannelAccountListContext(requestContext(ctx), openID)
+	if err != nil {
+		replyAPIError(ctx, "查询", err)
+		return nil, ref, false
+	}
+
+	if len(accounts) == 0 {
+		ctx.Reply("📋 暂无渠道账户")
+		return nil, ref, false
+	}
+
+	account, err := resolveChannelAccount(accounts, ref)
+	if err != nil {
+		ctx.Reply(fmt.Sprintf("❌ %s\n发送 /渠道列表 查看序号和ID", err.Error()))
+		return nil, ref, false
+	}
+
+	return account, ref, true
+}
+
+// maxChannelOnlineLogs 渠道详情最多显示的上下线记录数
+const maxChannelOnlineLogs = 5
+
+// formatChannelDetail 格式化渠道账户详情，群聊中名称和金额脱敏
+func formatChannelDetail(detail *ChannelAccountDetail, isPrivate bool) string {
+	var msg strings.Builder
+	msg.WriteString("📡 渠道详情\n\n")
+	msg.WriteString(fmt.Sprintf("渠道账户: %s (ID: %d)\n", displayAccountName(detail.Name, isPrivate), detail.ID))
+	msg.WriteString(fmt.Sprintf("支付方式: %s\n", detail.PayTypeName))
+	msg.WriteString(fmt.Sprintf("状态: %s | %s\n", formatChannelStatus(detail.Status), formatChannelOnline(detail.Online)))
+	msg.WriteString(fmt.Sprintf("最后心跳: %s\n", formatTime(detail.LastHeartbeat)))
+
+	if isPrivate {
+		limit := "不限额"
+		if detail.DayAmountLimit > 0 {
+			limit = "¥" + formatAmount(detail.DayAmountLimit)
+		}
+		msg.WriteString(fmt.Sprintf("今日额度: ¥%s / %s\n", formatAmount(detail.DayAmount), limit))
+	}
+	msg.WriteString(fmt.Sprintf("额度进度: %s\n", formatProgressBar(detail.DayAmount, detail.DayAmountLimit)))
+
+	if rate := detail.SuccessRate(); rate >= 0 {
+		msg.WriteString(fmt.Sprintf("今日成功率: %.1f%% (%d/%d)", rate, detail.TodaySuccessCount, detail.TodayOrderCount))
+	} else {
+		msg.WriteString("今日成功率: 暂无订单")
+	}
+
+	if len(detail.OnlineLogs) > 0 {
+		msg.WriteString("\n\n🕒 上下线记录")
+		logs := detail.OnlineLogs
+		if len(logs) > maxChannelOnlineLogs {
+			logs = logs[:maxChannelOnlineLogs]
+		}
+		for _, log := range logs {
+			msg.WriteString(fmt.Sprintf("\n%s %s", formatTime(log.Time), formatChannelOnline(log.Online)))
+		}
+	}
+
+	if len(detail.RecentOrders) > 0 {
+		msg.WriteString("\n\n🧾 最近订单")
+		for _, order := range detail.RecentOrders {
+			msg.WriteString(fmt.Sprintf("\n%s %s %s",
+				formatTime(order.CreateTime),
+				displayAmount(order.Amount, isPrivate),
+				formatOrderStatus(order.Status)))
+		}
+	}
+
+	return msg.String()
+}
+
+// registerChannelHandlers 注册渠道账户相关命令处理器
+func registerChannelHandlers(engine *xbot.Engine) {
+	// 渠道详情，参数按渠道ID匹配，与 /设置渠道限额 一致
+	engine.OnRegex(`^/渠道详情(?:\s+(\S+))?$`).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 || ctx.RegexResult.Groups[1] == "" {
+			ctx.Reply("❌ 请提供渠道ID\n用法: /渠道详情 <ID>\n渠道ID可在 /渠道列表 中查看")
+			return
+		}
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		account, _, ok := findChannelAccount(ctx, openID, ctx.RegexResult.Groups[1], true)
+		if !ok {
+			return
+		}
+
+		detail, err := client.GetChannelAccountDetailContext(requestContext(ctx), openID, account.ID)
+		if err != nil {
+			replyAPIError(ctx, "查询", err)
+			return
+		}
+
+		ctx.Reply(formatChannelDetail(detail, ctx.IsPrivateMessage()))
+	})
+
+	// 启用/禁用渠道，仅限私聊
+	engine.OnRegex(`^/(启用|禁用)渠道(?:\s+(\S+))?$`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 3 || ctx.RegexResult.Groups[2] == "" {
+			ctx.Reply("❌ 请提供渠道序号或ID\n用法: /启用渠道 <序号|ID12>\n      /禁用渠道 <序号|ID12>\n纯数字为 /渠道列表 中的序号，按渠道ID指定时需写作 ID12")
+			return
+		}
+
+		action := ctx.RegexResult.Groups[1] + "渠道"
+		enabled := ctx.RegexResult.Groups[1] == "启用"
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		account, ref, ok := findChannelAccount(ctx, openID, ctx.RegexResult.Groups[2], false)
+		if !ok {
+			return
+		}
+
+		if (account.Status == ChannelStatusEnabled) == enabled {
+			ctx.Reply(fmt.Sprintf("ℹ️ 渠道账户「%s」已是%s状态", account.Name, ctx.RegexResult.Groups[1]))
+			return
+		}
+
+		summary := fmt.Sprintf("🔧 %s确认\n\n"+
+			"匹配方式: %s\n"+
+			"渠道账户: %s (ID: %d)\n"+
+			"支付方式: %s\n"+
+			"在线状态: %s\n"+
+			"今日: ¥%s / ¥%s",
+			action,
+			ref,
+			account.Name,
+			account.ID,
+			account.PayTypeName,
+			formatChannelOnline(account.Online),
+			formatAmount(account.DayAmount),
+			formatAmount(account.DayAmountLimit))
+		if !enabled {
+			summary += "\n\n⚠️ 禁用后该渠道将不再接收新订单"
+		}
+
+		reqCtx := requestContext(ctx)
+		accountID, accountName := account.ID, account.Name
+		requestConfirm(ctx, action, summary, defaultConfirmTimeout, func(ctx *xbot.Context) {
+			if err := client.SetChannelAccountStatusContext(reqCtx, openID, accountID, enabled); err != nil {
+				replyAPIError(ctx, action, err)
+				return
+			}
+
+			ctx.Reply(fmt.Sprintf("✅ 已%s「%s」(ID: %d)", action, accountName, accountID))
+		})
+	})
+
+	// 设置渠道单日限额，仅限私聊
+	// 修改限额不经确认，参数只按渠道ID匹配，避免数字被当作序号改错渠道
+	engine.OnRegex(`^/设置渠道限额(?:\s+(\S+)\s+(\S+))?$`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 3 || ctx.RegexResult.Groups[2] == "" {
+			ctx.Reply("❌ 请提供渠道ID和限额\n用法: /设置渠道限额 <ID> <金额>\n示例: /设置渠道限额 12 5000\n渠道ID可在 /渠道列表 中查看")
+			return
+		}
+
+		limit, err := parseAmount(ctx.RegexResult.Groups[2])
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+			return
+		}
+		if limit <= 0 {
+			ctx.Reply("❌ 限额必须大于0")
+			return
+		}
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+
+		// 渠道限额不能超过套餐日限额
+		mealInfo, err := client.GetUserMealInfoContext(reqCtx, openID)
+		if err != nil {
+			replyAPIError(ctx, "查询", err)
+			return
+		}
+		if mealInfo.DayLimit != -1 && limit > mealInfo.DayLimit {
+			ctx.Reply(fmt.Sprintf("❌ 限额 ¥%s 超过套餐「%s」的日限额 ¥%s",
+				formatAmount(limit),
+				mealInfo.MealName,
+				formatAmount(mealInfo.DayLimit)))
+			return
+		}
+
+		account, _, ok := findChannelAccount(ctx, openID, ctx.RegexResult.Groups[1], true)
+		if !ok {
+			return
+		}
+
+		if err := client.SetChannelAccountDayLimitContext(reqCtx, openID, account.ID, limit); err != nil {
+			replyAPIError(ctx, "设置", err)
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 渠道限额已更新\n\n"+
+			"渠道账户: %s (ID: %d)\n"+
+			"单日限额: ¥%s → ¥%s\n"+
+			"今日已收: ¥%s",
+			account.Name,
+			account.ID,
+			formatAmount(account.DayAmountLimit),
+			formatAmount(limit),
+			formatAmount(account.DayAmount)))
+	})
+}
@@ -0,0 +1,49 @@
+package xarrmerchant
+
+import "testing"
+
+// TestResolveChannelAccount 纯数字只按序号匹配，ID前缀或byID时只按渠道ID匹配
+func TestResolveChannelAccount(t *testing.T) {
+	accounts := []ChannelAccount{
+		{ID: 12, Name: "支付宝1"},
+		{ID: 2, Name: "微信1"},
+		{ID: 3, Name: "微信2"},
+	}
+
+	tests := []struct {
+		ref    string
+		byID   bool
+		wantID int64 // 0 表示应报错
+	}{
+		{"1", false, 12},
+		{"2", false, 2},
+		{"12", false, 0}, // 超出序号范围时不按ID查找
+		{"ID12", false, 12},
+		{"id:3", false, 3},
+		{"ID1", false, 0},
+		{"2", true, 2},
+		{"1", true, 0},
+		{"0", false, 0},
+		{"abc", false, 0},
+	}
+
+	for _, tt := range tests {
+		ref, err := parseChannelRef(tt.ref, tt.byID)
+		if err != nil {
+			if tt.wantID != 0 {
+				t.Errorf("parseChannelRef(%q, %v) error: %v", tt.ref, tt.byID, err)
+			}
+			continue
+		}
+
+		account, err := resolveChannelAccount(accounts, ref)
+		switch {
+		case tt.wantID == 0 && err == nil:
+			t.Errorf("%q (byID=%v): matched ID %d, want error", tt.ref, tt.byID, account.ID)
+		case tt.wantID != 0 && err != nil:
+			t.Errorf("%q (byID=%v): %v", tt.ref, tt.byID, err)
+		case tt.wantID != 0 && account.ID != tt.wantID:
+			t.Errorf("%q (byID=%v): matched ID %d, want %d", tt.ref, tt.byID, account.ID, tt.wantID)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package xarrmerchant
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot"
+)
+
+// defaultConfirmTimeout 默认确认有效期
+const defaultConfirmTimeout = 60 * time.Second
+
+// pendingConfirm 等待用户确认的操作
+type pendingConfirm struct {
+	code      string
+	action    string // 操作名称，用于提示
+	run       func(ctx *xbot.Context)
+	expiresAt time.Time
+}
+
+var (
+	pendingConfirmsMu sync.Mutex
+	// 按 群号:QQ号 记录待确认操作，每个会话同时只保留最新的一个
+	pendingConfirms = map[string]*pendingConfirm{}
+)
+
+// generateConfirmCode 生成4位数字确认码
+func generateConfirmCode() string {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return fmt.Sprintf("%04d", time.Now().UnixNano()%10000)
+	}
+	return fmt.Sprintf("%04d", n.Int64())
+}
+
+// requestConfirm 发送操作摘要并等待用户回复确认码
+// 用户在有效期内发送 /确认 <确认码> 后执行run，发送 /取消 放弃操作
+func requestConfirm(ctx *xbot.Context, action, summary string, timeout time.Duration, run func(ctx *xbot.Context)) {
+	code := generateConfirmCode()
+
+	pendingConfirmsMu.Lock()
+	now := time.Now()
+	for key, pending := range pendingConfirms {
+		if now.After(pending.expiresAt) {
+			delete(pendingConfirms, key)
+		}
+	}
+	pendingConfirms[sessionKey(ctx)] = &pendingConfirm{
+		code:      code,
+		action:    action,
+		run:       run,
+		expiresAt: now.Add(timeout),
+	}
+	pendingConfirmsMu.Unlock()
+
+	ctx.Reply(fmt.Sprintf("%s\n\n"+
+		"⚠️ 请在 %d 秒内发送 /确认 %s 执行%s\n"+
+		"发送 /取消 放弃操作",
+		summary,
+		int(timeout/time.Second),
+		code,
+		action))
+}
+
+// takeConfirm 取出当前会话的待确认操作
+func takeConfirm(ctx *xbot.Context) (*pendingConfirm, bool) {
+	pendingConfirmsMu.Lock()
+	defer pendingConfirmsMu.Unlock()
+
+	key := sessionKey(ctx)
+	pending, ok := pendingConfirms[key]
+	if !ok {
+		return nil, false
+	}
+	delete(pendingConfirms, key)
+
+	if time.Now().After(pending.expiresAt) {
+		return pending, false
+	}
+	return pending, true
+}
+
+// registerConfirmHandlers 注册确认/取消命令处理器
+func registerConfirmHandlers(engine *xbot.Engine) {
+	engine.OnRegex(`^/确认\s+(\d{4})`).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 请提供确认码\n用法: /确认 <确认码>")
+			return
+		}
+
+		pendingConfirmsMu.Lock()
+		pending, ok := pendingConfirms[sessionKey(ctx)]
+		pendingConfirmsMu.Unlock()
+
+		if !ok {
+			ctx.Reply("❌ 没有等待确认的操作")
+			return
+		}
+
+		// 确认码错误时保留待确认操作，允许重新输入
+		if pending.code != ctx.RegexResult.Groups[1] {
+			ctx.Reply("❌ 确认码错误，请检查后重新发送")
+			return
+		}
+
+		pending, ok = takeConfirm(ctx)
+		if !ok {
+			if pending != nil {
+				ctx.Reply(fmt.Sprintf("❌ %s确认已超时，请重新发起", pending.action))
+			} else {
+				ctx.Reply("❌ 没有等待确认的操作")
+			}
+			return
+		}
+
+		pending.run(ctx)
+	})
+
+	engine.OnCommand("取消").Handle(func(ctx *xbot.Context) {
+		pending, ok := takeConfirm(ctx)
+		if pending == nil {
+			ctx.Reply("❌ 没有等待确认的操作")
+			return
+		}
+		if !ok {
+			ctx.Reply(fmt.Sprintf("ℹ️ %s确认已超时，无需取消", pending.action))
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 已取消%s", pending.action))
+	})
+}
@@ -0,0 +1,217 @@
+package xarrmerchant
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xiaoyi510/xbot"
+)
+
+// isCurrentMeal 判断套餐是否为用户当前套餐，旧版接口未返回套餐ID时按名称匹配
+func isCurrentMeal(meal Meal, current *UserMealInfo) bool {
+	if current == nil {
+		return false
+	}
+	if current.MealID > 0 {
+		return meal.ID == current.MealID
+	}
+	return meal.Name == current.MealName
+}
+
+// findMeal 在套餐列表中查找指定套餐
+func findMeal(meals []Meal, match func(Meal) bool) (Meal, bool) {
+	for _, meal := range meals {
+		if match(meal) {
+			return meal, true
+		}
+	}
+	return Meal{}, false
+}
+
+// formatMealList 格式化套餐列表
+func formatMealList(meals []Meal, current *UserMealInfo) string {
+	if len(meals) == 0 {
+		return "📦 暂无可购买的套餐"
+	}
+
+	var msg strings.Builder
+	msg.WriteString("📦 套餐列表\n\n")
+
+	for i, meal := range meals {
+		name := meal.Name
+		if isCurrentMeal(meal, current) {
+			name += " (当前)"
+		}
+
+		msg.WriteString(fmt.Sprintf("[%d] %s\n", meal.ID, name))
+		msg.WriteString(fmt.Sprintf("   价格: ¥%s / %s\n", formatAmount(meal.Price), formatMealDays(meal.Days)))
+		msg.WriteString(fmt.Sprintf("   通道账号数: %s\n", formatLimitCount(meal.ChannelAccountCount)))
+		msg.WriteString(fmt.Sprintf("   日限额: %s  月限额: %s\n", formatLimitAmount(meal.DayLimit), formatLimitAmount(meal.MonthLimit)))
+		msg.WriteString(fmt.Sprintf("   费率: %s", formatRate(meal.Rate)))
+		if meal.Remark != "" {
+			msg.WriteString(fmt.Sprintf("\n   说明: %s", meal.Remark))
+		}
+		if i < len(meals)-1 {
+			msg.WriteString("\n\n")
+		}
+	}
+
+	msg.WriteString("\n\n💡 发送 /购买套餐 <套餐ID> 购买，/续费 续费当前套餐")
+
+	return msg.String()
+}
+
+// confirmMealPurchase 校验余额并请求确认购买套餐
+func confirmMealPurchase(ctx *xbot.Context, reqCtx context.Context, openID, action string, meal Meal, current *UserMealInfo) {
+	balance, err := client.GetUserBalanceContext(reqCtx, openID)
+	if err != nil {
+		replyAPIError(ctx, "查询", err)
+		return
+	}
+
+	if balance < meal.Price {
+		ctx.Reply(fmt.Sprintf("❌ 余额不足\n\n"+
+			"套餐价格: ¥%s\n"+
+			"当前余额: ¥%s\n\n"+
+			"💡 发送 /充值 <金额> 充值后再试",
+			formatAmount(meal.Price),
+			formatAmount(balance)))
+		return
+	}
+
+	summary := fmt.Sprintf("📦 %s确认\n\n"+
+		"套餐: %s\n"+
+		"有效期: %s\n"+
+		"价格: ¥%s\n"+
+		"当前余额: ¥%s\n"+
+		"扣款后余额: ¥%s",
+		action,
+		meal.Name,
+		formatMealDays(meal.Days),
+		formatAmount(meal.Price),
+		formatAmount(balance),
+		formatAmount(balance-meal.Price))
+
+	if current != nil {
+		summary += fmt.Sprintf("\n\n当前套餐: %s (到期: %s)", current.MealName, formatExpireTime(current.ExpireTime))
+		if !isCurrentMeal(meal, current) {
+			summary += "\n⚠️ 购买后将替换当前套餐"
+		}
+	}
+
+	requestConfirm(ctx, action, summary, defaultConfirmTimeout, func(ctx *xbot.Context) {
+		result, err := client.BuyMealContext(reqCtx, openID, meal.ID)
+		if err != nil {
+			replyAPIError(ctx, action, err)
+			return
+		}
+
+		mealName := result.MealName
+		if mealName == "" {
+			mealName = meal.Name
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ %s成功\n\n"+
+			"套餐: %s\n"+
+			"扣款金额: ¥%s\n"+
+			"剩余余额: ¥%s\n"+
+			"到期时间: %s",
+			action,
+			mealName,
+			formatAmount(result.Amount),
+			formatAmount(result.Balance),
+			formatExpireTime(result.ExpireTime)))
+	})
+}
+
+// registerMealHandlers 注册套餐相关命令处理器
+func registerMealHandlers(engine *xbot.Engine) {
+	// 套餐列表
+	engine.OnCommand("套餐列表").Handle(func(ctx *xbot.Context) {
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+
+		meals, err := client.ListMealsContext(reqCtx, openID)
+		if err != nil {
+			replyAPIError(ctx, "查询", err)
+			return
+		}
+
+		// 当前套餐仅用于标记，查询失败不影响列表展示
+		current, _ := client.GetUserMealInfoContext(reqCtx, openID)
+
+		ctx.Reply(formatMealList(meals, current))
+	})
+
+	// 购买套餐
+	engine.OnRegex(`^/购买套餐(?:\s+(\S+))?$`).Handle(func(ctx *xbot.Context) {
+		var idText string
+		if ctx.RegexResult != nil && len(ctx.RegexResult.Groups) > 1 {
+			idText = ctx.RegexResult.Groups[1]
+		}
+
+		mealID, err := strconv.ParseInt(idText, 10, 64)
+		if err != nil || mealID <= 0 {
+			ctx.Reply("❌ 请提供套餐ID\n用法: /购买套餐 <套餐ID>\n发送 /套餐列表 查看可购买的套餐")
+			return
+		}
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+
+		meals, err := client.ListMealsContext(reqCtx, openID)
+		if err != nil {
+			replyAPIError(ctx, "查询", err)
+			return
+		}
+
+		meal, ok := findMeal(meals, func(m Meal) bool { return m.ID == mealID })
+		if !ok {
+			ctx.Reply(fmt.Sprintf("❌ 套餐 %d 不存在\n发送 /套餐列表 查看可购买的套餐", mealID))
+			return
+		}
+
+		// 当前套餐仅用于区分购买和续费，查询失败按购买处理
+		current, _ := client.GetUserMealInfoContext(reqCtx, openID)
+
+		action := "购买套餐"
+		if isCurrentMeal(meal, current) {
+			action = "续费"
+		}
+
+		confirmMealPurchase(ctx, reqCtx, openID, action, meal, current)
+	})
+
+	// 续费当前套餐
+	engine.OnCommand("续费").Handle(func(ctx *xbot.Context) {
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+
+		current, err := client.GetUserMealInfoContext(reqCtx, openID)
+		if err != nil {
+			replyAPIError(ctx, "查询", err)
+			return
+		}
+
+		if current.ExpireTime == -1 {
+			ctx.Reply(fmt.Sprintf("✅ 当前套餐「%s」永久有效，无需续费", current.MealName))
+			return
+		}
+
+		meals, err := client.ListMealsContext(reqCtx, openID)
+		if err != nil {
+			replyAPIError(ctx, "查询", err)
+			return
+		}
+
+		meal, ok := findMeal(meals, func(m Meal) bool { return isCurrentMeal(m, current) })
+		if !ok {
+			ctx.Reply(fmt.Sprintf("❌ 当前套餐「%s」不支持续费\n发送 /套餐列表 选择其他套餐购买", current.MealName))
+			return
+		}
+
+		confirmMealPurchase(ctx, reqCtx, openID, "续费", meal, current)
+	})
+}
@@ -0,0 +1,374 @@
+package xarrmerchant
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/xiaoyi510/xbot"
+)
+
+// registerNotifyHandlers 注册通知相关命令处理器
+func registerNotifyHandlers(engine *xbot.Engine) {
+	// 设置到账回调服务
+	engine.OnRegex(`^/设置商户回调\s+(\S+)`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /设置商户回调 <监听地址|关闭>\n示例: /设置商户回调 :8090")
+			return
+		}
+
+		listen := ctx.RegexResult.Groups[1]
+		if listen == "关闭" {
+			listen = ""
+		}
+
+		if listen == "" {
+			webhook.stop()
+		} else if err := webhook.start(listen); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 启动回调服务失败: %s", err.Error()))
+			return
+		}
+
+		if err := saveWebhookConfig(&WebhookConfig{Listen: listen}); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		if listen == "" {
+			ctx.Reply("✅ 到账回调服务已关闭")
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 到账回调服务已启动\n\n"+
+			"监听地址: %s\n"+
+			"回调地址: http://<服务器地址>%s\n"+
+			"非默认商户系统的回调地址为 %s/<名称>\n\n"+
+			"请在商户系统中将订单通知地址设置为以上地址",
+			listen,
+			webhookURLPath(DefaultProfile),
+			webhookPath))
+	})
+
+	// 设置主动推送接口
+	engine.OnRegex(`^/设置商户推送\s+(\S+)(?:\s+(\S+))?`, xbot.OnlyPrivateMessage(), xbot.OnlySuperUsers()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /设置商户推送 <OneBot HTTP地址|关闭> [access_token]\n示例: /设置商户推送 http://127.0.0.1:3000")
+			return
+		}
+
+		config := &PushConfig{}
+		if apiURL := ctx.RegexResult.Groups[1]; apiURL != "关闭" {
+			var err error
+			if config.APIURL, err = parsePushURL(apiURL); err != nil {
+				ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+				return
+			}
+			if len(ctx.RegexResult.Groups) > 2 {
+				config.AccessToken = ctx.RegexResult.Groups[2]
+			}
+		}
+
+		if err := savePushConfig(config); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		if config.APIURL == "" {
+			ctx.Reply("✅ 主动推送已关闭\n到账通知、渠道告警等将无法送达")
+			return
+		}
+
+		// 发送一条测试消息确认接口可用
+		if err := pushPrivate(ctx.GetUserID(), "✅ 主动推送测试消息"); err != nil {
+			ctx.Reply(fmt.Sprintf("⚠️ 已保存，但测试推送失败: %s\n请检查 OneBot HTTP API 地址和 access_token", err.Error()))
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 主动推送接口已设置\n\n接口地址: %s", config.APIURL))
+	})
+
+	// 开启/关闭到账通知，私聊中设置私聊推送，群聊中设置推送到本群
+	engine.OnRegex(`^/到账通知\s+(开启|关闭)`).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /到账通知 <开启|关闭>")
+			return
+		}
+
+		enabled := ctx.RegexResult.Groups[1] == "开启"
+		userID := ctx.GetUserID()
+		groupID := groupIDOf(ctx)
+
+		// 群聊使用群分配的商户系统；私聊沿用已订阅的商户系统，保证与UID一致
+		profile := client.ResolveProfile(userID, groupID)
+		if groupID == 0 {
+			profile = privateProfileOf(userID)
+		}
+
+		// 开启时查询商户UID，用于匹配回调中的商户
+		var uid int64
+		if enabled {
+			userInfo, err := client.GetUserInfoContext(WithProfile(pluginCtx, profile), strconv.FormatInt(userID, 10))
+			if err != nil {
+				replyAPIError(ctx, "开启", err)
+				return
+			}
+			uid = userInfo.UID
+		}
+
+		err := updateSubscription(userID, func(sub *Subscription) {
+			if groupID == 0 {
+				sub.Private = enabled
+				if enabled {
+					sub.Profile = profile
+					sub.UID = uid
+				}
+				return
+			}
+
+			if !enabled {
+				delete(sub.GroupTargets, groupID)
+				return
+			}
+			if sub.GroupTargets == nil {
+				sub.GroupTargets = map[int64]NotifyTarget{}
+			}
+			sub.GroupTargets[groupID] = NotifyTarget{Profile: profile, UID: uid}
+		})
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		target := "私聊"
+		if groupID != 0 {
+			target = "本群"
+		}
+
+		if !enabled {
+			ctx.Reply(fmt.Sprintf("✅ 已关闭%s到账通知", target))
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 已开启%s到账通知\n收到支付成功回调后将推送到%s", target, target))
+	})
+
+	// 开启/关闭渠道告警，仅限私聊
+	engine.OnRegex(`^/渠道告警\s+(开启|关闭)`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 参数不完整\n用法: /渠道告警 <开启|关闭>")
+			return
+		}
+
+		enabled := ctx.RegexResult.Groups[1] == "开启"
+		userID := ctx.GetUserID()
+		// 巡检使用的商户系统，已订阅其他功能时沿用
+		profile := privateProfileOf(userID)
+
+		// 开启时以当前渠道状态为基准，避免沿用旧状态误报
+		states := map[int64]*channelState{}
+		if enabled {
+			accounts, err := client.GetChannelAccountListContext(WithProfile(pluginCtx, profile), strconv.FormatInt(userID, 10))
+			if err != nil {
+				replyAPIError(ctx, "开启", err)
+				return
+			}
+			diffChannelStates(states, accounts, time.Now())
+		}
+
+		if err := saveChannelStates(userID, states); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		err := updateSubscription(userID, func(sub *Subscription) {
+			if enabled && sub.Profile == "" {
+				sub.Profile = profile
+			}
+			sub.ChannelAlert = enabled
+		})
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		if !enabled {
+			ctx.Reply("✅ 已关闭渠道告警")
+			return
+		}
+
+		ctx.Reply(fmt.Sprintf("✅ 已开启渠道告警，当前监控 %d 个渠道\n"+
+			"渠道离线、被禁用或恢复时将私聊通知您\n"+
+			"状态需连续 %d 次巡检(每 %d 秒)保持一致才会通知",
+			len(states),
+			channelAlertDebounce,
+			int(monitorInterval/time.Second)))
+	})
+
+	// 额度预警开关和阈值，仅限私聊
+	engine.OnRegex(`^/额度告警(?:\s+(开启|关闭|阈值)(?:\s+(\S+))?)?$`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
+		userID := ctx.GetUserID()
+
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 || ctx.RegexResult.Groups[1] == "" {
+			sub, err := getSubscription(userID)
+			if err != nil {
+				ctx.Reply(fmt.Sprintf("❌ 读取设置失败: %s", err.Error()))
+				return
+			}
+
+			status, thresholds := false, defaultLimitThresholds
+			if sub != nil {
+				status, thresholds = sub.LimitAlert, thresholdsOf(*sub)
+			}
+
+			ctx.Reply(fmt.Sprintf("📈 额度预警: %s\n预警阈值: %s\n\n"+
+				"用法: /额度告警 <开启|关闭>\n"+
+				"      /额度告警 阈值 <80,95,100|默认>",
+				formatSwitch(status),
+				formatLimitThresholds(thresholds)))
+			return
+		}
+
+		var update func(sub *Subscription)
+		var reply string
+
+		switch ctx.RegexResult.Groups[1] {
+		case "阈值":
+			var value string
+			if len(ctx.RegexResult.Groups) > 2 {
+				value = ctx.RegexResult.Groups[2]
+			}
+			if value == "" {
+				ctx.Reply("❌ 请提供阈值\n用法: /额度告警 阈值 <80,95,100|默认>")
+				return
+			}
+
+			var thresholds []int
+			if value != "默认" {
+				var err error
+				if thresholds, err = parseLimitThresholds(value); err != nil {
+					ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+					return
+				}
+			}
+
+			update = func(sub *Subscription) { sub.LimitThresholds = thresholds }
+			if thresholds == nil {
+				thresholds = defaultLimitThresholds
+			}
+			reply = fmt.Sprintf("✅ 额度预警阈值已设置为 %s", formatLimitThresholds(thresholds))
+
+		case "开启":
+			// 巡检使用的商户系统，已订阅其他功能时沿用
+			profile := privateProfileOf(userID)
+
+			// 确认已绑定，避免巡检时反复失败
+			if _, err := client.GetUserMealInfoContext(WithProfile(pluginCtx, profile), strconv.FormatInt(userID, 10)); err != nil {
+				replyAPIError(ctx, "开启", err)
+				return
+			}
+
+			update = func(sub *Subscription) {
+				if sub.Profile == "" {
+					sub.Profile = profile
+				}
+				sub.LimitAlert = true
+			}
+			reply = "✅ 已开启额度预警\n渠道今日额度、套餐日/月限额达到预警阈值时将私聊通知您，每个阈值每个周期只通知一次"
+
+		default:
+			update = func(sub *Subscription) { sub.LimitAlert = false }
+			reply = "✅ 已关闭额度预警"
+		}
+
+		if err := updateSubscription(userID, update); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		ctx.Reply(reply)
+	})
+
+	// 套餐到期提醒开关和提醒天数，仅限私聊
+	engine.OnRegex(`^/续费提醒(?:\s+(开启|关闭|天数)(?:\s+(\S+))?)?$`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
+		userID := ctx.GetUserID()
+
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 || ctx.RegexResult.Groups[1] == "" {
+			sub, err := getSubscription(userID)
+			if err != nil {
+				ctx.Reply(fmt.Sprintf("❌ 读取设置失败: %s", err.Error()))
+				return
+			}
+
+			status, days := "未开启", defaultExpiryReminderDays
+			if sub != nil && sub.Profile != "" {
+				status, days = formatSwitch(!sub.ExpiryReminderOff), reminderDaysOf(*sub)
+			}
+
+			ctx.Reply(fmt.Sprintf("⏰ 续费提醒: %s\n提前提醒: %s及到期当天\n\n"+
+				"用法: /续费提醒 <开启|关闭>\n"+
+				"      /续费提醒 天数 <7,3,1|默认>",
+				status,
+				formatReminderDays(days)))
+			return
+		}
+
+		var update func(sub *Subscription)
+		var reply string
+
+		switch ctx.RegexResult.Groups[1] {
+		case "天数":
+			var value string
+			if len(ctx.RegexResult.Groups) > 2 {
+				value = ctx.RegexResult.Groups[2]
+			}
+			if value == "" {
+				ctx.Reply("❌ 请提供天数\n用法: /续费提醒 天数 <7,3,1|默认>")
+				return
+			}
+
+			var days []int
+			if value != "默认" {
+				var err error
+				if days, err = parseReminderDays(value); err != nil {
+					ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+					return
+				}
+			}
+
+			update = func(sub *Subscription) { sub.ExpiryReminderDays = days }
+			if days == nil {
+				days = defaultExpiryReminderDays
+			}
+			reply = fmt.Sprintf("✅ 将在到期前 %s及到期当天提醒", formatReminderDays(days))
+
+		case "开启":
+			// 巡检使用的商户系统，已订阅其他功能时沿用
+			profile := privateProfileOf(userID)
+
+			// 确认已绑定，绑定早于此功能的用户也通过此命令加入提醒
+			if _, err := client.GetUserMealInfoContext(WithProfile(pluginCtx, profile), strconv.FormatInt(userID, 10)); err != nil {
+				replyAPIError(ctx, "开启", err)
+				return
+			}
+
+			update = func(sub *Subscription) {
+				if sub.Profile == "" {
+					sub.Profile = profile
+				}
+				sub.ExpiryReminderOff = false
+			}
+			reply = fmt.Sprintf("✅ 已开启续费提醒\n套餐到期前和到期当天 %d 点后将私聊提醒您，永久套餐不会提醒", expiryReminderHour)
+
+		default:
+			update = func(sub *Subscription) { sub.ExpiryReminderOff = true }
+			reply = "✅ 已关闭续费提醒"
+		}
+
+		if err := updateSubscription(userID, update); err != nil {
+			ctx.Reply(fmt.Sprintf("❌ 保存失败: %s", err.Error()))
+			return
+		}
+
+		ctx.Reply(reply)
+	})
+}
@@ -0,0 +1,387 @@
+package xarrmerchant
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xiaoyi510/xbot"
+)
+
+// registerOrderHandlers 注册订单相关命令处理器
+func registerOrderHandlers(engine *xbot.Engine) {
+	// 查询订单
+	engine.OnRegex(`^/查单\s+(\S+)`).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 请提供订单号\n用法: /查单 <商户订单号|平台订单号>")
+			return
+		}
+
+		orderNo := ctx.RegexResult.Groups[1]
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+
+		order, err := client.FindOrderContext(requestContext(ctx), openID, orderNo)
+		if err != nil {
+			replyOrderError(ctx, "查询", orderNo, err)
+			return
+		}
+
+		ctx.Reply(formatOrderDetail(order, ctx.IsPrivateMessage()))
+	})
+
+	// 重发支付通知
+	engine.OnRegex(`^/(?:补单|重发通知)\s+(\S+)`).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 请提供订单号\n用法: /补单 <订单号>")
+			return
+		}
+
+		orderNo := ctx.RegexResult.Groups[1]
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+
+		order, err := client.FindOrderContext(reqCtx, openID, orderNo)
+		if err != nil {
+			replyOrderError(ctx, "查询", orderNo, err)
+			return
+		}
+
+		if order.Status != OrderStatusPaid {
+			ctx.Reply(fmt.Sprintf("❌ 订单%s，无法重发通知", formatOrderStatus(order.Status)))
+			return
+		}
+
+		summary := fmt.Sprintf("🔔 重发支付通知\n\n"+
+			"商户订单号: %s\n"+
+			"订单金额: %s\n"+
+			"支付时间: %s\n"+
+			"通知状态: %s",
+			order.OutTradeNo,
+			displayAmount(order.Amount, ctx.IsPrivateMessage()),
+			formatTime(order.PayTime),
+			formatNotifyStatus(order.NotifyStatus))
+
+		requestConfirm(ctx, "重发通知", summary, defaultConfirmTimeout, func(ctx *xbot.Context) {
+			result, err := client.RenotifyOrderContext(reqCtx, openID, order.TradeNo)
+			if err != nil {
+				replyOrderError(ctx, "重发通知", orderNo, err)
+				return
+			}
+
+			ctx.Reply(formatNotifyResult(order, result))
+		})
+	})
+
+	// 发起退款，仅限私聊
+	engine.OnRegex(`^/退款\s+(\S+)(?:\s+(\S+))?`, xbot.OnlyPrivateMessage()).Handle(func(ctx *xbot.Context) {
+		if ctx.RegexResult == nil || len(ctx.RegexResult.Groups) < 2 {
+			ctx.Reply("❌ 请提供订单号\n用法: /退款 <订单号> [金额]\n不填金额时全额退款")
+			return
+		}
+
+		orderNo := ctx.RegexResult.Groups[1]
+		var amountText string
+		if len(ctx.RegexResult.Groups) > 2 {
+			amountText = ctx.RegexResult.Groups[2]
+		}
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+
+		order, err := client.FindOrderContext(reqCtx, openID, orderNo)
+		if err != nil {
+			replyOrderError(ctx, "查询", orderNo, err)
+			return
+		}
+
+		if order.Status != OrderStatusPaid {
+			ctx.Reply(fmt.Sprintf("❌ 订单%s，无法退款", formatOrderStatus(order.Status)))
+			return
+		}
+
+		// 可退金额 = 实付金额 - 已退款金额
+		paidAmount := order.RealAmount
+		if paidAmount <= 0 {
+			paidAmount = order.Amount
+		}
+		refundable := paidAmount - order.RefundAmount
+		if refundable <= 0 {
+			ctx.Reply("❌ 该订单已无可退金额")
+			return
+		}
+
+		amount := refundable
+		if amountText != "" {
+			amount, err = parseAmount(amountText)
+			if err != nil {
+				ctx.Reply(fmt.Sprintf("❌ %s", err.Error()))
+				return
+			}
+			if amount <= 0 {
+				ctx.Reply("❌ 退款金额必须大于0")
+				return
+			}
+			if amount > refundable {
+				ctx.Reply(fmt.Sprintf("❌ 退款金额 ¥%s 超过可退金额 ¥%s\n(实付 ¥%s，已退 ¥%s)",
+					formatAmount(amount),
+					formatAmount(refundable),
+					formatAmount(paidAmount),
+					formatAmount(order.RefundAmount)))
+				return
+			}
+		}
+
+		refundType := "全额退款"
+		if amount < refundable || order.RefundAmount > 0 {
+			refundType = "部分退款"
+		}
+
+		summary := fmt.Sprintf("↩️ 退款确认\n\n"+
+			"商户订单号: %s\n"+
+			"商品名称: %s\n"+
+			"支付方式: %s\n"+
+			"实付金额: ¥%s\n"+
+			"已退金额: ¥%s\n"+
+			"本次退款: ¥%s (%s)\n"+
+			"支付时间: %s",
+			order.OutTradeNo,
+			order.Name,
+			order.PayTypeName,
+			formatAmount(paidAmount),
+			formatAmount(order.RefundAmount),
+			formatAmount(amount),
+			refundType,
+			formatTime(order.PayTime))
+
+		requestConfirm(ctx, "退款", summary, defaultConfirmTimeout, func(ctx *xbot.Context) {
+			result, err := client.RefundOrderContext(reqCtx, openID, order.TradeNo, amount)
+			if err != nil {
+				replyOrderError(ctx, "退款", orderNo, err)
+				return
+			}
+
+			msg := fmt.Sprintf("↩️ 退款结果\n\n"+
+				"商户订单号: %s\n"+
+				"退款单号: %s\n"+
+				"退款金额: ¥%s\n"+
+				"退款状态: %s",
+				order.OutTradeNo,
+				result.RefundNo,
+				formatAmount(result.Amount),
+				formatRefundStatus(result.Status))
+			if result.Message != "" {
+				msg += "\n说明: " + result.Message
+			}
+
+			ctx.Reply(msg)
+		})
+	})
+
+	// 订单列表
+	engine.OnRegex(`^/订单列表(?:\s+(.+))?$`).Handle(func(ctx *xbot.Context) {
+		var args string
+		if ctx.RegexResult != nil && len(ctx.RegexResult.Groups) > 1 {
+			args = ctx.RegexResult.Groups[1]
+		}
+
+		filter, err := parseOrderFilter(args)
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ %s\n\n%s", err.Error(), orderListUsage))
+			return
+		}
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+		isPrivate := ctx.IsPrivateMessage()
+
+		load := func(page int) (string, int, error) {
+			f := filter
+			f.Page = page
+			result, err := client.ListOrdersContext(reqCtx, openID, f)
+			if err != nil {
+				return "", 0, err
+			}
+			return formatOrderPage(result, isPrivate), result.TotalPages(), nil
+		}
+
+		showPage(ctx, "查询", load, filter.Page)
+	})
+}
+
+// orderListUsage 订单列表用法说明
+const orderListUsage = "用法: /订单列表 [页码] [筛选条件...]\n" +
+	"筛选条件:\n" +
+	"  状态=已支付|未支付|已关闭|已退款\n" +
+	"  方式=<支付类型>，如 alipay\n" +
+	"  渠道=<渠道账户ID>\n" +
+	"  日期=今天|昨天|近7天|本月|2006-01-02|2006-01-01~2006-01-31\n" +
+	"  金额=<最小>~<最大>，单位元\n" +
+	"示例: /订单列表 状态=已支付 日期=近7天 金额=10~100"
+
+// orderStatusNames 订单状态名称
+var orderStatusNames = map[string]int{
+	"未支付": OrderStatusUnpaid,
+	"已支付": OrderStatusPaid,
+	"已关闭": OrderStatusClosed,
+	"已退款": OrderStatusRefunded,
+}
+
+// parseOrderFilter 解析订单列表参数
+func parseOrderFilter(args string) (OrderFilter, error) {
+	filter := OrderFilter{Page: 1, PageSize: 10}
+
+	for _, field := range strings.Fields(args) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			// 不带key的数字视为页码
+			page, err := strconv.Atoi(field)
+			if err != nil || page < 1 {
+				return filter, fmt.Errorf("无法识别的参数: %s", field)
+			}
+			filter.Page = page
+			continue
+		}
+
+		switch key {
+		case "状态":
+			status, ok := orderStatusNames[value]
+			if !ok {
+				return filter, fmt.Errorf("无效的订单状态: %s", value)
+			}
+			filter.Status = &status
+		case "方式":
+			filter.PayType = value
+		case "渠道":
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				return filter, fmt.Errorf("无效的渠道账户ID: %s", value)
+			}
+			filter.ChannelAccountID = id
+		case "日期":
+			start, end, err := parseDateRange(value)
+			if err != nil {
+				return filter, err
+			}
+			filter.StartTime, filter.EndTime = start, end
+		case "金额":
+			minText, maxText, _ := strings.Cut(value, "~")
+			if minText != "" {
+				amount, err := parseAmount(minText)
+				if err != nil {
+					return filter, err
+				}
+				filter.MinAmount = amount
+			}
+			if maxText != "" {
+				amount, err := parseAmount(maxText)
+				if err != nil {
+					return filter, err
+				}
+				filter.MaxAmount = amount
+			}
+			if filter.MaxAmount > 0 && filter.MinAmount > filter.MaxAmount {
+				return filter, errors.New("最小金额不能大于最大金额")
+			}
+		default:
+			return filter, fmt.Errorf("无法识别的筛选条件: %s", key)
+		}
+	}
+
+	return filter, nil
+}
+
+// formatOrderPage 格式化订单列表
+func formatOrderPage(page *OrderPage, isPrivate bool) string {
+	if len(page.List) == 0 {
+		return "📋 暂无符合条件的订单"
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("📋 订单列表 (共%d笔)\n\n", page.Total))
+
+	offset := (page.Page - 1) * page.PageSize
+	for i, order := range page.List {
+		msg.WriteString(fmt.Sprintf("%d. %s\n", offset+i+1, order.OutTradeNo))
+		msg.WriteString(fmt.Sprintf("   %s | %s | %s\n",
+			displayAmount(order.Amount, isPrivate),
+			order.PayTypeName,
+			formatOrderStatus(order.Status)))
+		msg.WriteString(fmt.Sprintf("   渠道: %s\n", displayAccountName(order.ChannelAccountName, isPrivate)))
+		msg.WriteString(fmt.Sprintf("   时间: %s", formatTime(order.CreateTime)))
+		if i < len(page.List)-1 {
+			msg.WriteString("\n\n")
+		}
+	}
+
+	return msg.String()
+}
+
+// maxNotifyResponseLen 通知返回内容最多显示的字符数
+const maxNotifyResponseLen = 200
+
+// formatNotifyResult 格式化重发通知结果
+func formatNotifyResult(order *Order, result *NotifyResult) string {
+	title := "✅ 通知发送成功"
+	if !result.Success {
+		title = "❌ 通知发送失败"
+	}
+
+	var msg strings.Builder
+	msg.WriteString(title + "\n\n")
+	msg.WriteString(fmt.Sprintf("商户订单号: %s\n", order.OutTradeNo))
+	if result.HTTPStatus > 0 {
+		msg.WriteString(fmt.Sprintf("HTTP状态码: %d\n", result.HTTPStatus))
+	}
+	msg.WriteString(fmt.Sprintf("耗时: %dms\n", result.Duration))
+	if result.Error != "" {
+		msg.WriteString(fmt.Sprintf("失败原因: %s\n", result.Error))
+	}
+
+	response := []rune(strings.TrimSpace(result.Response))
+	if len(response) == 0 {
+		msg.WriteString("返回内容: (空)")
+	} else if len(response) > maxNotifyResponseLen {
+		msg.WriteString(fmt.Sprintf("返回内容: %s...", string(response[:maxNotifyResponseLen])))
+	} else {
+		msg.WriteString(fmt.Sprintf("返回内容: %s", string(response)))
+	}
+
+	if !result.Success {
+		msg.WriteString("\n\n💡 商户通知地址需返回 success 才视为通知成功")
+	}
+
+	return msg.String()
+}
+
+// replyOrderError 回复订单相关错误，订单不存在时提示订单号
+func replyOrderError(ctx *xbot.Context, action, orderNo string, err error) {
+	if errors.Is(err, ErrOrderNotFound) {
+		ctx.Reply(fmt.Sprintf("❌ 未找到订单: %s\n请确认订单号是否正确", orderNo))
+		return
+	}
+	replyAPIError(ctx, action, err)
+}
+
+// formatOrderDetail 格式化订单详情，群聊中对金额和账户名称掩码处理
+func formatOrderDetail(order *Order, isPrivate bool) string {
+	var msg strings.Builder
+	msg.WriteString("🧾 订单详情\n\n")
+	msg.WriteString(fmt.Sprintf("商户订单号: %s\n", order.OutTradeNo))
+	msg.WriteString(fmt.Sprintf("平台订单号: %s\n", order.TradeNo))
+	if order.Name != "" {
+		msg.WriteString(fmt.Sprintf("商品名称: %s\n", displayAccountName(order.Name, isPrivate)))
+	}
+	msg.WriteString(fmt.Sprintf("订单金额: %s\n", displayAmount(order.Amount, isPrivate)))
+	if order.RealAmount > 0 && order.RealAmount != order.Amount {
+		msg.WriteString(fmt.Sprintf("实付金额: %s\n", displayAmount(order.RealAmount, isPrivate)))
+	}
+	msg.WriteString(fmt.Sprintf("支付方式: %s\n", order.PayTypeName))
+	msg.WriteString(fmt.Sprintf("渠道账户: %s\n", displayAccountName(order.ChannelAccountName, isPrivate)))
+	msg.WriteString(fmt.Sprintf("订单状态: %s\n", formatOrderStatus(order.Status)))
+	msg.WriteString(fmt.Sprintf("通知状态: %s\n", formatNotifyStatus(order.NotifyStatus)))
+	msg.WriteString(fmt.Sprintf("创建时间: %s\n", formatTime(order.CreateTime)))
+	msg.WriteString(fmt.Sprintf("支付时间: %s", formatTime(order.PayTime)))
+
+	return msg.String()
+}
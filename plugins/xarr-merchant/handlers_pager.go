@@ -0,0 +1,97 @@
+package xarrmerchant
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot"
+)
+
+// pageSessionTTL 翻页会话有效期
+const pageSessionTTL = 10 * time.Minute
+
+// pageLoader 加载指定页，返回消息内容和总页数
+type pageLoader func(page int) (text string, totalPages int, err error)
+
+// pageSession 分页列表会话，记录用户最近一次查看的列表
+type pageSession struct {
+	action     string // 操作名称，用于错误提示
+	load       pageLoader
+	page       int
+	totalPages int
+	expiresAt  time.Time
+}
+
+var (
+	pageSessionsMu sync.Mutex
+	// 按 群号:QQ号 记录翻页会话，私聊群号为0
+	pageSessions = map[string]*pageSession{}
+)
+
+// showPage 显示列表的指定页，并记录翻页会话
+func showPage(ctx *xbot.Context, action string, load pageLoader, page int) {
+	text, totalPages, err := load(page)
+	if err != nil {
+		replyAPIError(ctx, action, err)
+		return
+	}
+
+	if totalPages > 1 {
+		text += fmt.Sprintf("\n\n📄 第 %d/%d 页，发送 /上一页 或 /下一页 翻页", page, totalPages)
+	}
+
+	pageSessionsMu.Lock()
+	// 顺带清理过期会话
+	now := time.Now()
+	for key, session := range pageSessions {
+		if now.After(session.expiresAt) {
+			delete(pageSessions, key)
+		}
+	}
+	pageSessions[sessionKey(ctx)] = &pageSession{
+		action:     action,
+		load:       load,
+		page:       page,
+		totalPages: totalPages,
+		expiresAt:  now.Add(pageSessionTTL),
+	}
+	pageSessionsMu.Unlock()
+
+	ctx.Reply(text)
+}
+
+// turnPage 在最近一次查看的列表上翻页
+func turnPage(ctx *xbot.Context, delta int) {
+	pageSessionsMu.Lock()
+	session, ok := pageSessions[sessionKey(ctx)]
+	pageSessionsMu.Unlock()
+
+	if !ok || time.Now().After(session.expiresAt) {
+		ctx.Reply("❌ 没有可以翻页的列表，请重新查询")
+		return
+	}
+
+	page := session.page + delta
+	if page < 1 {
+		ctx.Reply("❌ 已经是第一页了")
+		return
+	}
+	if page > session.totalPages {
+		ctx.Reply("❌ 已经是最后一页了")
+		return
+	}
+
+	showPage(ctx, session.action, session.load, page)
+}
+
+// registerPagerHandlers 注册翻页命令处理器
+func registerPagerHandlers(engine *xbot.Engine) {
+	engine.OnCommand("下一页").Handle(func(ctx *xbot.Context) {
+		turnPage(ctx, 1)
+	})
+
+	engine.OnCommand("上一页").Handle(func(ctx *xbot.Context) {
+		turnPage(ctx, -1)
+	})
+}
@@ -0,0 +1,227 @@
+package xarrmerchant
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/xiaoyi510/xbot"
+	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/message"
+)
+
+const (
+	// paymentPollInterval 等待支付时查询订单状态的间隔
+	paymentPollInterval = 5 * time.Second
+	// defaultPaymentTimeout 订单未返回过期时间时的最长等待时间
+	defaultPaymentTimeout = 10 * time.Minute
+	// maxPaymentAmount 单笔收款上限(分)
+	maxPaymentAmount = 5000000
+)
+
+// payTypeAliases 支付方式别名
+var payTypeAliases = map[string]string{
+	"支付宝":    "alipay",
+	"alipay": "alipay",
+	"微信":     "wxpay",
+	"wxpay":  "wxpay",
+	"QQ":     "qqpay",
+	"qq":     "qqpay",
+	"qqpay":  "qqpay",
+}
+
+var (
+	watchingMu sync.Mutex
+	// 正在等待支付的订单，避免重复监听
+	watching = map[string]bool{}
+)
+
+// renderQRCode 生成二维码图片消息段内容(base64://)
+func renderQRCode(content string) (string, error) {
+	png, err := qrcode.Encode(content, qrcode.Medium, 320)
+	if err != nil {
+		return "", err
+	}
+	return "base64://" + base64.StdEncoding.EncodeToString(png), nil
+}
+
+// buildPaymentMessage 构造包含支付链接和二维码的消息
+func buildPaymentMessage(title string, payment *PaymentOrder) any {
+	content := payment.QRCode
+	if content == "" {
+		content = payment.PayURL
+	}
+
+	text := fmt.Sprintf("%s\n\n"+
+		"订单号: %s\n"+
+		"金额: ¥%s\n"+
+		"支付链接: %s",
+		title,
+		payment.OutTradeNo,
+		formatAmount(payment.Amount),
+		payment.PayURL)
+	if payment.ExpireTime > 0 {
+		text += fmt.Sprintf("\n有效期至: %s", formatTime(payment.ExpireTime))
+	}
+
+	image, err := renderQRCode(content)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("生成支付二维码失败: %s", err.Error()))
+		return text
+	}
+
+	return message.NewBuilder().
+		Text(text + "\n").
+		Image(image).
+		Build()
+}
+
+// paymentDeadline 计算等待支付的截止时间
+func paymentDeadline(expireTime int64) time.Time {
+	deadline := time.Now().Add(defaultPaymentTimeout)
+	if expireTime > 0 {
+		if expire := time.Unix(expireTime, 0); expire.Before(deadline) {
+			deadline = expire
+		}
+	}
+	return deadline
+}
+
+// watchPayment 在后台定时查询直到支付完成或超时
+// check 返回true表示已完成；超时后调用onTimeout(可为nil)
+func watchPayment(reqCtx context.Context, key string, deadline time.Time, check func(ctx context.Context) bool, onTimeout func()) {
+	watchingMu.Lock()
+	if watching[key] {
+		watchingMu.Unlock()
+		return
+	}
+	watching[key] = true
+	watchingMu.Unlock()
+
+	go func() {
+		defer func() {
+			watchingMu.Lock()
+			delete(watching, key)
+			watchingMu.Unlock()
+		}()
+
+		ctx, cancel := context.WithDeadline(reqCtx, deadline)
+		defer cancel()
+
+		ticker := time.NewTicker(paymentPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// 机器人退出时不再提示超时
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) && onTimeout != nil {
+					onTimeout()
+				}
+				return
+			case <-ticker.C:
+				if check(ctx) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// parsePaymentArgs 解析收款参数: <金额> [备注] [支付方式]
+// 最后一个参数是已知支付方式时视为支付方式，其余作为备注
+func parsePaymentArgs(args string) (CreateOrderRequest, error) {
+	var req CreateOrderRequest
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return req, errors.New("请提供收款金额")
+	}
+
+	amount, err := parseAmount(fields[0])
+	if err != nil {
+		return req, err
+	}
+	if amount <= 0 {
+		return req, errors.New("收款金额必须大于0")
+	}
+	if amount > maxPaymentAmount {
+		return req, fmt.Errorf("单笔收款金额不能超过 ¥%s", formatAmount(maxPaymentAmount))
+	}
+	req.Amount = amount
+
+	rest := fields[1:]
+	if len(rest) > 0 {
+		if payType, ok := payTypeAliases[rest[len(rest)-1]]; ok {
+			req.PayType = payType
+			rest = rest[:len(rest)-1]
+		}
+	}
+
+	req.Name = strings.Join(rest, " ")
+	if req.Name == "" {
+		req.Name = "机器人收款"
+	}
+
+	return req, nil
+}
+
+// registerPaymentHandlers 注册收款相关命令处理器
+func registerPaymentHandlers(engine *xbot.Engine) {
+	// 创建收款订单
+	engine.OnRegex(`^/收款(?:\s+(.+))?$`).Handle(func(ctx *xbot.Context) {
+		var args string
+		if ctx.RegexResult != nil && len(ctx.RegexResult.Groups) > 1 {
+			args = ctx.RegexResult.Groups[1]
+		}
+
+		req, err := parsePaymentArgs(args)
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ %s\n用法: /收款 <金额> [备注] [支付宝|微信|QQ]\n示例: /收款 88.8 定金 支付宝", err.Error()))
+			return
+		}
+
+		openID := strconv.FormatInt(ctx.GetUserID(), 10)
+		reqCtx := requestContext(ctx)
+
+		payment, err := client.CreateOrderContext(reqCtx, openID, req)
+		if err != nil {
+			replyAPIError(ctx, "创建订单", err)
+			return
+		}
+
+		ctx.Reply(buildPaymentMessage("💳 收款订单已创建", payment))
+
+		// 等待支付完成后通知
+		watchPayment(reqCtx, "order:"+payment.TradeNo, paymentDeadline(payment.ExpireTime), func(pollCtx context.Context) bool {
+			order, err := client.GetOrderByTradeNoContext(pollCtx, openID, payment.TradeNo)
+			if err != nil || order.Status == OrderStatusUnpaid {
+				return false
+			}
+
+			if order.Status != OrderStatusPaid {
+				ctx.Reply(fmt.Sprintf("❌ 订单 %s 已关闭", payment.OutTradeNo))
+				return true
+			}
+
+			ctx.Reply(fmt.Sprintf("🎉 收款到账\n\n"+
+				"订单号: %s\n"+
+				"金额: ¥%s\n"+
+				"支付方式: %s\n"+
+				"支付时间: %s",
+				order.OutTradeNo,
+				formatAmount(order.Amount),
+				order.PayTypeName,
+				formatTime(order.PayTime)))
+			return true
+		}, func() {
+			ctx.Reply(fmt.Sprintf("⌛ 订单 %s 未在有效期内支付，已停止等待", payment.OutTradeNo))
+		})
+	})
+}
@@ -1,6 +1,8 @@
 package xarrmerchant
 
 import (
+	"context"
+
 	"github.com/xiaoyi510/xbot"
 	"github.com/xiaoyi510/xbot/logger"
 	"github.com/xiaoyi510/xbot/storage"
@@ -11,12 +13,26 @@ var (
 	client *MerchantClient
 	// 插件专属storage
 	storageDB storage.Storage
+	// 插件生命周期context，机器人退出时取消所有未完成的API请求
+	pluginCtx context.Context
+	// 取消pluginCtx，由 Shutdown 调用
+	pluginCancel context.CancelFunc
 )
 
+// Shutdown 在框架退出后调用，取消未完成的请求并关闭后台任务
+// 插件不监听退出信号，信号由框架自身处理
+func Shutdown() {
+	pluginCancel()
+	webhook.stop()
+	logger.Info("商户机器人插件正在退出，已取消未完成的请求")
+}
+
 func init() {
 	engine := xbot.NewEngine()
 	engine.UseRecovery().UseLogger()
 
+	pluginCtx, pluginCancel = context.WithCancel(context.Background())
+
 	// 初始化插件专属storage
 	storageDB = xbot.GetStorage("xarr_merchant")
 
@@ -27,11 +43,44 @@ func init() {
 	// 注册超管命令
 	registerAdminHandlers(engine)
 
+	// 注册多商户系统管理命令
+	registerProfileHandlers(engine)
+
 	// 注册用户命令
 	registerUserHandlers(engine)
 
+	// 注册订单命令
+	registerOrderHandlers(engine)
+
+	// 注册资金命令
+	registerBalanceHandlers(engine)
+
+	// 注册套餐命令
+	registerMealHandlers(engine)
+
+	// 注册渠道命令
+	registerChannelHandlers(engine)
+
+	// 注册收款命令
+	registerPaymentHandlers(engine)
+
+	// 注册通知命令
+	registerNotifyHandlers(engine)
+
+	// 注册翻页命令
+	registerPagerHandlers(engine)
+
+	// 注册确认命令
+	registerConfirmHandlers(engine)
+
 	// 注册群消息处理
 	registerGroupMessageHandler(engine)
 
+	// 启动到账回调服务
+	startWebhook()
+
+	// 启动后台巡检
+	startMonitor()
+
 	logger.Info("商户机器人插件已加载")
 }
@@ -0,0 +1,206 @@
+package xarrmerchant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiaoyi510/xbot/logger"
+)
+
+const (
+	// monitorInterval 后台巡检间隔
+	monitorInterval = time.Minute
+	// channelAlertDebounce 状态连续保持多少次巡检后才确认变化，避免短暂掉线误报
+	channelAlertDebounce = 2
+	// channelStateKeyPrefix 渠道状态存储key前缀，后接QQ号
+	channelStateKeyPrefix = "merchant:channel-state:"
+)
+
+// channelState 渠道账户最近一次确认的状态
+type channelState struct {
+	Name      string `json:"name"`
+	Online    int    `json:"online"`     // 已确认的在线状态
+	Status    int    `json:"status"`     // 已确认的启用状态
+	Pending   int    `json:"pending"`    // 与已确认状态不同的连续巡检次数
+	ChangedAt int64  `json:"changed_at"` // 状态确认变化的时间
+}
+
+// channelStateKey 渠道状态存储key
+func channelStateKey(userID int64) string {
+	return channelStateKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// loadChannelStates 读取用户的渠道状态
+func loadChannelStates(userID int64) (map[int64]*channelState, error) {
+	states := map[int64]*channelState{}
+
+	data, err := storageDB.Get(channelStateKey(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		if err := json.Unmarshal(data, &states); err != nil {
+			return nil, err
+		}
+	}
+
+	return states, nil
+}
+
+// saveChannelStates 保存用户的渠道状态
+func saveChannelStates(userID int64, states map[int64]*channelState) error {
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	return storageDB.Set(channelStateKey(userID), data)
+}
+
+// diffChannelStates 对比渠道列表与已确认状态，返回需要推送的变化说明
+// 新出现的渠道直接记录不告警，已删除的渠道移除记录
+func diffChannelStates(states map[int64]*channelState, accounts []ChannelAccount, now time.Time) []string {
+	var changes []string
+	seen := make(map[int64]bool, len(accounts))
+
+	for _, acc := range accounts {
+		seen[acc.ID] = true
+
+		state, ok := states[acc.ID]
+		if !ok {
+			states[acc.ID] = &channelState{
+				Name:      acc.Name,
+				Online:    acc.Online,
+				Status:    acc.Status,
+				ChangedAt: now.Unix(),
+			}
+			continue
+		}
+		state.Name = acc.Name
+
+		if acc.Online == state.Online && acc.Status == state.Status {
+			state.Pending = 0
+			continue
+		}
+
+		state.Pending++
+		if state.Pending < channelAlertDebounce {
+			continue
+		}
+
+		// 已确认变化，变化前状态持续的时间
+		lasted := formatDuration(now.Sub(time.Unix(state.ChangedAt, 0)))
+		name := fmt.Sprintf("「%s」(ID: %d)", acc.Name, acc.ID)
+
+		switch {
+		case state.Online == ChannelOnline && acc.Online == ChannelOffline:
+			changes = append(changes, fmt.Sprintf("📴 %s 已离线", name))
+		case state.Online == ChannelOffline && acc.Online == ChannelOnline:
+			changes = append(changes, fmt.Sprintf("✅ %s 已恢复在线 (离线约 %s)", name, lasted))
+		}
+
+		switch {
+		case state.Status == ChannelStatusEnabled && acc.Status == ChannelStatusDisabled:
+			changes = append(changes, fmt.Sprintf("⛔ %s 已被禁用", name))
+		case state.Status == ChannelStatusDisabled && acc.Status == ChannelStatusEnabled:
+			changes = append(changes, fmt.Sprintf("✅ %s 已重新启用", name))
+		}
+
+		state.Online = acc.Online
+		state.Status = acc.Status
+		state.Pending = 0
+		state.ChangedAt = now.Unix()
+	}
+
+	for id := range states {
+		if !seen[id] {
+			delete(states, id)
+		}
+	}
+
+	return changes
+}
+
+// checkChannelStatus 检查用户的渠道状态变化并推送告警
+func checkChannelStatus(ctx context.Context, sub Subscription) {
+	accounts, err := client.GetChannelAccountListContext(ctx, strconv.FormatInt(sub.UserID, 10))
+	if err != nil {
+		// 查询失败时保留原状态，下次巡检再判断
+		logger.Warn(fmt.Sprintf("巡检用户 %d 的渠道状态失败: %s", sub.UserID, err.Error()))
+		return
+	}
+
+	states, err := loadChannelStates(sub.UserID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("读取用户 %d 的渠道状态失败: %s", sub.UserID, err.Error()))
+		return
+	}
+
+	now := time.Now()
+	changes := diffChannelStates(states, accounts, now)
+
+	if len(changes) > 0 {
+		msg := fmt.Sprintf("⚠️ 渠道状态变化\n\n%s\n\n时间: %s\n发送 /渠道列表 查看全部渠道",
+			strings.Join(changes, "\n"),
+			formatTime(now.Unix()))
+		if err := pushPrivate(sub.UserID, msg); err != nil {
+			// 推送失败时不保存新状态，下次巡检仍会确认变化并重新告警
+			logger.Warn(fmt.Sprintf("推送渠道告警给 %d 失败: %s", sub.UserID, err.Error()))
+			return
+		}
+	}
+
+	if err := saveChannelStates(sub.UserID, states); err != nil {
+		logger.Error(fmt.Sprintf("保存用户 %d 的渠道状态失败: %s", sub.UserID, err.Error()))
+	}
+}
+
+// runMonitor 执行一次后台巡检
+func runMonitor() {
+	subs, err := listSubscriptions()
+	if err != nil {
+		logger.Error(fmt.Sprintf("读取通知订阅失败: %s", err.Error()))
+		return
+	}
+
+	for _, sub := range subs {
+		if pluginCtx.Err() != nil {
+			return
+		}
+		if sub.Profile == "" {
+			continue
+		}
+
+		ctx := WithProfile(pluginCtx, sub.Profile)
+		if sub.ChannelAlert {
+			checkChannelStatus(ctx, sub)
+		}
+		if sub.LimitAlert {
+			checkLimits(ctx, sub)
+		}
+		if !sub.ExpiryReminderOff {
+			checkMealExpiry(ctx, sub)
+		}
+	}
+}
+
+// startMonitor 启动后台巡检，机器人退出时停止
+func startMonitor() {
+	go func() {
+		ticker := time.NewTicker(monitorInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-pluginCtx.Done():
+				return
+			case <-ticker.C:
+				runMonitor()
+			}
+		}
+	}()
+}
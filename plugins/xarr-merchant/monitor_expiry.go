@@ -0,0 +1,148 @@
+package xarrmerchant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiaoyi510/xbot/logger"
+)
+
+const (
+	// expiryStateKeyPrefix 到期提醒状态存储key前缀，后接QQ号，值为最近一次检查的日期
+	expiryStateKeyPrefix = "merchant:expiry-state:"
+	// expiryReminderHour 每天几点后发送到期提醒
+	expiryReminderHour = 10
+	// maxExpiryReminderDays 到期前提醒天数上限
+	maxExpiryReminderDays = 30
+)
+
+// defaultExpiryReminderDays 默认到期前提醒天数
+var defaultExpiryReminderDays = []int{7, 3, 1}
+
+// expiryStateKey 到期提醒状态存储key
+func expiryStateKey(userID int64) string {
+	return expiryStateKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// reminderDaysOf 用户的到期前提醒天数
+func reminderDaysOf(sub Subscription) []int {
+	if len(sub.ExpiryReminderDays) == 0 {
+		return defaultExpiryReminderDays
+	}
+	return sub.ExpiryReminderDays
+}
+
+// parseReminderDays 解析逗号分隔的提醒天数，返回降序去重结果
+func parseReminderDays(text string) ([]int, error) {
+	text = strings.ReplaceAll(text, "，", ",")
+
+	var days []int
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSuffix(strings.TrimSpace(part), "天")
+		if part == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 || n > maxExpiryReminderDays {
+			return nil, fmt.Errorf("无效的天数: %s，范围 1-%d", part, maxExpiryReminderDays)
+		}
+		days = append(days, n)
+	}
+
+	if len(days) == 0 {
+		return nil, errors.New("请至少设置一个天数")
+	}
+
+	slices.Sort(days)
+	days = slices.Compact(days)
+	slices.Reverse(days)
+	return days, nil
+}
+
+// formatReminderDays 格式化提醒天数
+func formatReminderDays(days []int) string {
+	parts := make([]string, len(days))
+	for i, d := range days {
+		parts[i] = fmt.Sprintf("%d天", d)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// daysUntil 计算到期日与今天相差的自然日天数，当天到期为0
+func daysUntil(expireTime int64, now time.Time) int {
+	expire := time.Unix(expireTime, 0)
+	expireDay := time.Date(expire.Year(), expire.Month(), expire.Day(), 0, 0, 0, 0, time.Local)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	return int(expireDay.Sub(today).Round(time.Hour).Hours()) / 24
+}
+
+// checkMealExpiry 每天检查一次套餐到期时间，到达提醒天数或当天到期时推送提醒
+func checkMealExpiry(ctx context.Context, sub Subscription) {
+	now := time.Now()
+	if now.Hour() < expiryReminderHour {
+		return
+	}
+
+	today := now.Format("2006-01-02")
+	data, err := storageDB.Get(expiryStateKey(sub.UserID))
+	if err != nil {
+		logger.Error(fmt.Sprintf("读取用户 %d 的到期提醒状态失败: %s", sub.UserID, err.Error()))
+		return
+	}
+	if string(data) == today {
+		return
+	}
+
+	mealInfo, err := client.GetUserMealInfoContext(ctx, strconv.FormatInt(sub.UserID, 10))
+	if err != nil {
+		// 查询失败时不记录日期，下次巡检重试
+		logger.Warn(fmt.Sprintf("巡检用户 %d 的套餐到期时间失败: %s", sub.UserID, err.Error()))
+		return
+	}
+
+	// 永久套餐(-1)不提醒
+	var title string
+	if mealInfo.ExpireTime > 0 {
+		switch days := daysUntil(mealInfo.ExpireTime, now); {
+		case days == 0:
+			title = "⏰ 套餐今天到期"
+		case days > 0 && slices.Contains(reminderDaysOf(sub), days):
+			title = fmt.Sprintf("⏰ 套餐将在 %d 天后到期", days)
+		}
+	}
+
+	// 今天无需提醒时直接记录日期
+	if title == "" {
+		markExpiryChecked(sub.UserID, today)
+		return
+	}
+
+	msg := fmt.Sprintf("%s\n\n"+
+		"套餐: %s\n"+
+		"到期时间: %s\n\n"+
+		"💡 发送 /续费 即可使用余额一键续费\n"+
+		"发送 /续费提醒 关闭 可关闭此提醒",
+		title,
+		mealInfo.MealName,
+		formatTime(mealInfo.ExpireTime))
+	if err := pushPrivate(sub.UserID, msg); err != nil {
+		// 推送失败时不记录日期，下次巡检重试
+		logger.Warn(fmt.Sprintf("推送到期提醒给 %d 失败: %s", sub.UserID, err.Error()))
+		return
+	}
+
+	markExpiryChecked(sub.UserID, today)
+}
+
+// markExpiryChecked 记录用户当天已完成到期检查
+func markExpiryChecked(userID int64, today string) {
+	if err := storageDB.Set(expiryStateKey(userID), []byte(today)); err != nil {
+		logger.Error(fmt.Sprintf("保存用户 %d 的到期提醒状态失败: %s", userID, err.Error()))
+	}
+}
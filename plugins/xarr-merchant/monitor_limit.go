@@ -0,0 +1,221 @@
+package xarrmerchant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiaoyi510/xbot/logger"
+)
+
+// limitStateKeyPrefix 额度预警状态存储key前缀，后接QQ号
+const limitStateKeyPrefix = "merchant:limit-state:"
+
+// defaultLimitThresholds 默认额度预警阈值(百分比)
+var defaultLimitThresholds = []int{80, 95, 100}
+
+// limitFired 某项额度在当前周期内已推送的最高阈值
+type limitFired struct {
+	Period    string `json:"period"`    // 周期，日限额为 2006-01-02，月限额为 2006-01
+	Threshold int    `json:"threshold"` // 已推送的最高阈值
+}
+
+// limitStateKey 额度预警状态存储key
+func limitStateKey(userID int64) string {
+	return limitStateKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// loadLimitStates 读取用户的额度预警状态
+func loadLimitStates(userID int64) (map[string]*limitFired, error) {
+	states := map[string]*limitFired{}
+
+	data, err := storageDB.Get(limitStateKey(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		if err := json.Unmarshal(data, &states); err != nil {
+			return nil, err
+		}
+	}
+
+	return states, nil
+}
+
+// saveLimitStates 保存用户的额度预警状态
+func saveLimitStates(userID int64, states map[string]*limitFired) error {
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	return storageDB.Set(limitStateKey(userID), data)
+}
+
+// parseLimitThresholds 解析逗号分隔的阈值列表，返回升序去重结果
+func parseLimitThresholds(text string) ([]int, error) {
+	text = strings.NewReplacer("，", ",", "%", "").Replace(text)
+
+	var thresholds []int
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 || n > 100 {
+			return nil, fmt.Errorf("无效的阈值: %s，范围 1-100", part)
+		}
+		thresholds = append(thresholds, n)
+	}
+
+	if len(thresholds) == 0 {
+		return nil, errors.New("请至少设置一个阈值")
+	}
+
+	slices.Sort(thresholds)
+	return slices.Compact(thresholds), nil
+}
+
+// formatLimitThresholds 格式化阈值列表
+func formatLimitThresholds(thresholds []int) string {
+	parts := make([]string, len(thresholds))
+	for i, t := range thresholds {
+		parts[i] = fmt.Sprintf("%d%%", t)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// thresholdsOf 用户的额度预警阈值
+func thresholdsOf(sub Subscription) []int {
+	if len(sub.LimitThresholds) == 0 {
+		return defaultLimitThresholds
+	}
+	return sub.LimitThresholds
+}
+
+// crossedThreshold 计算本周期内新达到的最高阈值，没有新达到的阈值时返回0
+// 一次跨过多个阈值时只推送最高的一个
+func crossedThreshold(states map[string]*limitFired, key, period string, used, limit int64, thresholds []int) int {
+	if limit <= 0 {
+		return 0
+	}
+
+	state, ok := states[key]
+	if !ok || state.Period != period {
+		state = &limitFired{Period: period}
+		states[key] = state
+	}
+
+	crossed := 0
+	for _, t := range thresholds {
+		if used*100 >= limit*int64(t) && t > state.Threshold {
+			crossed = t
+		}
+	}
+
+	if crossed > 0 {
+		state.Threshold = crossed
+	}
+	return crossed
+}
+
+// formatLimitWarning 格式化一条额度预警
+func formatLimitWarning(title string, threshold int, used, limit int64) string {
+	icon := "⚠️"
+	if threshold >= 100 {
+		icon = "🚫"
+	}
+
+	return fmt.Sprintf("%s %s 已达 %d%%\n   ¥%s / ¥%s %s",
+		icon,
+		title,
+		threshold,
+		formatAmount(used),
+		formatAmount(limit),
+		formatProgressBar(used, limit))
+}
+
+// checkLimits 检查渠道和套餐额度使用情况并推送预警
+func checkLimits(ctx context.Context, sub Subscription) {
+	openID := strconv.FormatInt(sub.UserID, 10)
+
+	accounts, err := client.GetChannelAccountListContext(ctx, openID)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("巡检用户 %d 的渠道额度失败: %s", sub.UserID, err.Error()))
+		return
+	}
+
+	mealInfo, err := client.GetUserMealInfoContext(ctx, openID)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("巡检用户 %d 的套餐额度失败: %s", sub.UserID, err.Error()))
+		return
+	}
+
+	// 套餐不限额时无需查询统计
+	var stat *UserPayStat
+	if mealInfo.DayLimit > 0 || mealInfo.MonthLimit > 0 {
+		if stat, err = client.GetUserPayStatContext(ctx, openID); err != nil {
+			logger.Warn(fmt.Sprintf("巡检用户 %d 的收款统计失败: %s", sub.UserID, err.Error()))
+			return
+		}
+	}
+
+	states, err := loadLimitStates(sub.UserID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("读取用户 %d 的额度预警状态失败: %s", sub.UserID, err.Error()))
+		return
+	}
+
+	now := time.Now()
+	day, month := now.Format("2006-01-02"), now.Format("2006-01")
+	thresholds := thresholdsOf(sub)
+
+	var warnings []string
+	for _, acc := range accounts {
+		key := fmt.Sprintf("channel:%d", acc.ID)
+		if t := crossedThreshold(states, key, day, acc.DayAmount, acc.DayAmountLimit, thresholds); t > 0 {
+			title := fmt.Sprintf("渠道「%s」(ID: %d) 今日额度", acc.Name, acc.ID)
+			warnings = append(warnings, formatLimitWarning(title, t, acc.DayAmount, acc.DayAmountLimit))
+		}
+	}
+
+	if stat != nil {
+		if t := crossedThreshold(states, "meal:day", day, stat.TodayAmount, mealInfo.DayLimit, thresholds); t > 0 {
+			warnings = append(warnings, formatLimitWarning("套餐日限额", t, stat.TodayAmount, mealInfo.DayLimit))
+		}
+		if t := crossedThreshold(states, "meal:month", month, stat.MonthAmount, mealInfo.MonthLimit, thresholds); t > 0 {
+			warnings = append(warnings, formatLimitWarning("套餐月限额", t, stat.MonthAmount, mealInfo.MonthLimit))
+		}
+	}
+
+	// 已删除的渠道不再保留状态
+	for key := range states {
+		if id, ok := strings.CutPrefix(key, "channel:"); ok {
+			if !slices.ContainsFunc(accounts, func(acc ChannelAccount) bool { return strconv.FormatInt(acc.ID, 10) == id }) {
+				delete(states, key)
+			}
+		}
+	}
+
+	if len(warnings) > 0 {
+		msg := fmt.Sprintf("📈 额度预警\n\n%s\n\n时间: %s\n💡 可使用 /设置渠道限额 调整渠道限额，或 /套餐列表 升级套餐",
+			strings.Join(warnings, "\n"),
+			formatTime(now.Unix()))
+		if err := pushPrivate(sub.UserID, msg); err != nil {
+			// 推送失败时不记录已推送的阈值，下次巡检重新预警
+			logger.Warn(fmt.Sprintf("推送额度预警给 %d 失败: %s", sub.UserID, err.Error()))
+			return
+		}
+	}
+
+	if err := saveLimitStates(sub.UserID, states); err != nil {
+		logger.Error(fmt.Sprintf("保存用户 %d 的额度预警状态失败: %s", sub.UserID, err.Error()))
+	}
+}
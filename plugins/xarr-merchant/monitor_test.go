@@ -0,0 +1,122 @@
+package xarrmerchant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFakeMerchant 启动按接口路径返回固定data的模拟商户系统，并设为默认商户系统
+func newFakeMerchant(t *testing.T, responses map[string]string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		data, ok := responses[r.URL.Path]
+		if !ok {
+			rw.Write([]byte(`{"code":404,"message":"not found"}`))
+			return
+		}
+		rw.Write([]byte(`{"code":200,"message":"ok","data":` + data + `}`))
+	}))
+	t.Cleanup(server.Close)
+
+	if err := client.SaveProfileConfig(DefaultProfile, &MerchantConfig{BaseURL: server.URL, Secret: "test_secret"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCheckChannelStatusRetriesFailedPush 推送失败时不保存新状态，下次巡检重新告警
+func TestCheckChannelStatusRetriesFailedPush(t *testing.T) {
+	bot := newFakeOneBot(t)
+	newFakeMerchant(t, map[string]string{
+		"/api/system-api/channel-account/list": `[{"id":1,"name":"支付宝1","status":1,"online":0}]`,
+	})
+
+	sub := Subscription{UserID: 20001, Profile: DefaultProfile, ChannelAlert: true}
+	t.Cleanup(func() { removeSubscription(sub.UserID) })
+
+	err := saveChannelStates(sub.UserID, map[int64]*channelState{
+		1: {Name: "支付宝1", Online: ChannelOnline, Status: ChannelStatusEnabled},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	online := func() int {
+		states, err := loadChannelStates(sub.UserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return states[1].Online
+	}
+
+	// 第一次巡检只累计，不告警
+	checkChannelStatus(ctx, sub)
+	if got := bot.sent.Load(); got != 0 {
+		t.Fatalf("pushed %d times before debounce, want 0", got)
+	}
+
+	// 确认变化但推送失败，保留原状态
+	bot.fail.Store(true)
+	checkChannelStatus(ctx, sub)
+	if online() != ChannelOnline {
+		t.Fatal("state saved as offline although the alert was not delivered")
+	}
+
+	// 推送恢复后重新告警并保存
+	bot.fail.Store(false)
+	checkChannelStatus(ctx, sub)
+	if got := bot.sent.Load(); got != 1 {
+		t.Fatalf("pushed %d times, want 1", got)
+	}
+	if online() != ChannelOffline {
+		t.Fatal("state not saved after the alert was delivered")
+	}
+}
+
+// TestCheckLimitsRetriesFailedPush 推送失败时不记录阈值，下次巡检重新预警
+func TestCheckLimitsRetriesFailedPush(t *testing.T) {
+	bot := newFakeOneBot(t)
+	newFakeMerchant(t, map[string]string{
+		"/api/system-api/channel-account/list": `[{"id":1,"name":"支付宝1","status":1,"online":1,"day_amount":9000,"day_amount_limit":10000}]`,
+		"/api/system-api/user/meal-info":       `{"meal_name":"基础版","day_limit":-1,"month_limit":-1}`,
+	})
+
+	sub := Subscription{UserID: 20002, Profile: DefaultProfile, LimitAlert: true}
+	t.Cleanup(func() { removeSubscription(sub.UserID) })
+
+	ctx := context.Background()
+	fired := func() int {
+		states, err := loadLimitStates(sub.UserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state, ok := states["channel:1"]; ok {
+			return state.Threshold
+		}
+		return 0
+	}
+
+	bot.fail.Store(true)
+	checkLimits(ctx, sub)
+	if got := fired(); got != 0 {
+		t.Fatalf("threshold %d recorded although the warning was not delivered", got)
+	}
+
+	bot.fail.Store(false)
+	checkLimits(ctx, sub)
+	if got := bot.sent.Load(); got != 1 {
+		t.Fatalf("pushed %d times, want 1", got)
+	}
+	if got := fired(); got != 80 {
+		t.Fatalf("recorded threshold %d, want 80", got)
+	}
+
+	// 同一周期内不再重复预警
+	checkLimits(ctx, sub)
+	if got := bot.sent.Load(); got != 1 {
+		t.Fatalf("pushed %d times after repeat check, want 1", got)
+	}
+}
@@ -0,0 +1,360 @@
+package xarrmerchant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/imroc/req/v3"
+)
+
+const (
+	// DefaultProfile 默认商户系统名称，配置存储在 ConfigKey 下以兼容旧版本
+	DefaultProfile = "default"
+	// profileIndexKey 商户系统索引存储key
+	profileIndexKey = "merchant:profiles"
+	// profileKeyPrefix 非默认商户系统配置存储key前缀
+	profileKeyPrefix = "merchant:profile:"
+)
+
+// profileNamePattern 商户系统名称格式
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-\p{Han}]{1,32}$`)
+
+// ProfileIndex 商户系统索引
+type ProfileIndex struct {
+	Active string           `json:"active"` // 默认使用的商户系统
+	Names  []string         `json:"names"`  // 已添加的商户系统(不含default)
+	Groups map[int64]string `json:"groups"` // 群聊 → 商户系统
+	Users  map[int64]string `json:"users"`  // 用户 → 商户系统
+
+	AllowedGroups []int64 `json:"allowed_groups"` // 群聊白名单，所有商户系统共用
+}
+
+// backend 每个商户系统独立的运行状态
+type backend struct {
+	breaker *circuitBreaker
+	clock   *clockSkew
+
+	httpMu  sync.Mutex
+	http    *req.Client // 按连接配置构建的HTTP客户端
+	httpKey string      // 构建http时使用的连接配置指纹
+}
+
+// profileContextKey context中保存商户系统名称的key
+type profileContextKey struct{}
+
+// WithProfile 指定请求使用的商户系统
+func WithProfile(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, profileContextKey{}, name)
+}
+
+// profileFromContext 获取context中指定的商户系统，未指定时返回空
+func profileFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(profileContextKey{}).(string)
+	return name
+}
+
+// profileKey 商户系统配置存储key
+func profileKey(name string) string {
+	if name == DefaultProfile {
+		return ConfigKey
+	}
+	return profileKeyPrefix + name
+}
+
+// validateProfileName 校验商户系统名称
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return errors.New("名称只能包含中文、字母、数字、下划线和横线，最长32个字符")
+	}
+	return nil
+}
+
+// loadIndex 读取商户系统索引，调用方需持有configMu
+func (c *MerchantClient) loadIndex() (*ProfileIndex, error) {
+	index := &ProfileIndex{}
+
+	data, err := c.storage.Get(profileIndexKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		if err := json.Unmarshal(data, index); err != nil {
+			return nil, err
+		}
+	}
+
+	if index.Active == "" {
+		index.Active = DefaultProfile
+	}
+	if index.Groups == nil {
+		index.Groups = map[int64]string{}
+	}
+	if index.Users == nil {
+		index.Users = map[int64]string{}
+	}
+
+	// 旧版本的白名单保存在默认商户系统的配置中
+	if index.AllowedGroups == nil {
+		index.AllowedGroups = []int64{}
+		if config, err := c.loadProfile(DefaultProfile); err == nil && config.AllowedGroups != nil {
+			index.AllowedGroups = config.AllowedGroups
+		}
+	}
+
+	return index, nil
+}
+
+// saveIndex 保存商户系统索引，调用方需持有configMu写锁
+func (c *MerchantClient) saveIndex(index *ProfileIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return c.storage.Set(profileIndexKey, data)
+}
+
+// loadProfile 读取商户系统配置，调用方需持有configMu
+func (c *MerchantClient) loadProfile(name string) (*MerchantConfig, error) {
+	data, err := c.storage.Get(profileKey(name))
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil {
+		if name == DefaultProfile {
+			return nil, ErrNotConfigured
+		}
+		return nil, fmt.Errorf("%w: 商户系统 %s 不存在", ErrNotConfigured, name)
+	}
+
+	var config MerchantConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// GetIndex 获取商户系统索引
+func (c *MerchantClient) GetIndex() (*ProfileIndex, error) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return c.loadIndex()
+}
+
+// ActiveProfile 获取默认使用的商户系统名称
+func (c *MerchantClient) ActiveProfile() string {
+	index, err := c.GetIndex()
+	if err != nil {
+		return DefaultProfile
+	}
+	return index.Active
+}
+
+// GetProfileConfig 获取指定商户系统的配置
+func (c *MerchantClient) GetProfileConfig(name string) (*MerchantConfig, error) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return c.loadProfile(name)
+}
+
+// SaveProfileConfig 保存指定商户系统的配置，不存在时新增
+func (c *MerchantClient) SaveProfileConfig(name string, config *MerchantConfig) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	if err := c.storage.Set(profileKey(name), data); err != nil {
+		return err
+	}
+
+	// 配置变更后重建HTTP客户端，使证书等文件的修改生效
+	c.backend(name).resetHTTPClient()
+
+	if name == DefaultProfile {
+		return nil
+	}
+
+	index, err := c.loadIndex()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(index.Names, name) {
+		return nil
+	}
+	index.Names = append(index.Names, name)
+	return c.saveIndex(index)
+}
+
+// ListProfiles 获取所有已配置的商户系统名称，default 在首位
+func (c *MerchantClient) ListProfiles() ([]string, error) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	index, err := c.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(index.Names)+1)
+	if data, err := c.storage.Get(ConfigKey); err == nil && data != nil {
+		names = append(names, DefaultProfile)
+	}
+	names = append(names, index.Names...)
+
+	return names, nil
+}
+
+// DeleteProfile 删除商户系统，同时移除相关的群聊和用户分配
+func (c *MerchantClient) DeleteProfile(name string) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	if name == DefaultProfile {
+		return errors.New("默认商户系统不能删除")
+	}
+
+	index, err := c.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(index.Names, name) {
+		return fmt.Errorf("商户系统 %s 不存在", name)
+	}
+	if index.Active == name {
+		return errors.New("不能删除正在使用的默认商户系统，请先切换")
+	}
+
+	if err := c.storage.Delete(profileKey(name)); err != nil {
+		return err
+	}
+
+	index.Names = slices.DeleteFunc(index.Names, func(n string) bool { return n == name })
+	for groupID, profile := range index.Groups {
+		if profile == name {
+			delete(index.Groups, groupID)
+		}
+	}
+	for userID, profile := range index.Users {
+		if profile == name {
+			delete(index.Users, userID)
+		}
+	}
+
+	c.backendsMu.Lock()
+	delete(c.backends, name)
+	c.backendsMu.Unlock()
+
+	return c.saveIndex(index)
+}
+
+// SetActiveProfile 切换默认使用的商户系统
+func (c *MerchantClient) SetActiveProfile(name string) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	if _, err := c.loadProfile(name); err != nil {
+		return err
+	}
+
+	index, err := c.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	index.Active = name
+	return c.saveIndex(index)
+}
+
+// AssignGroup 为群聊分配商户系统，name为空时取消分配
+func (c *MerchantClient) AssignGroup(groupID int64, name string) error {
+	return c.assign(groupID, name, func(index *ProfileIndex) map[int64]string { return index.Groups })
+}
+
+// AssignUser 为用户分配商户系统，name为空时取消分配
+func (c *MerchantClient) AssignUser(userID int64, name string) error {
+	return c.assign(userID, name, func(index *ProfileIndex) map[int64]string { return index.Users })
+}
+
+// assign 更新群聊或用户的商户系统分配
+func (c *MerchantClient) assign(id int64, name string, target func(*ProfileIndex) map[int64]string) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	if name != "" {
+		if _, err := c.loadProfile(name); err != nil {
+			return err
+		}
+	}
+
+	index, err := c.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		delete(target(index), id)
+	} else {
+		target(index)[id] = name
+	}
+
+	return c.saveIndex(index)
+}
+
+// ResolveProfile 确定请求应使用的商户系统
+// 群聊中: 群分配 > 用户分配 > 默认；私聊中: 用户分配 > 默认
+func (c *MerchantClient) ResolveProfile(userID, groupID int64) string {
+	index, err := c.GetIndex()
+	if err != nil {
+		return DefaultProfile
+	}
+
+	if groupID != 0 {
+		if name, ok := index.Groups[groupID]; ok {
+			return name
+		}
+	}
+	if name, ok := index.Users[userID]; ok {
+		return name
+	}
+
+	return index.Active
+}
+
+// backend 获取商户系统的运行状态，不存在时创建
+func (c *MerchantClient) backend(name string) *backend {
+	c.backendsMu.Lock()
+	defer c.backendsMu.Unlock()
+
+	b, ok := c.backends[name]
+	if !ok {
+		b = &backend{
+			breaker: newCircuitBreaker(defaultFailureThreshold, defaultOpenDuration),
+			clock:   &clockSkew{},
+		}
+		c.backends[name] = b
+	}
+
+	return b
+}
+
+// profileForRequest 获取请求使用的商户系统名称，context未指定时使用默认
+func (c *MerchantClient) profileForRequest(ctx context.Context) string {
+	if name := profileFromContext(ctx); name != "" {
+		return name
+	}
+	return c.ActiveProfile()
+}
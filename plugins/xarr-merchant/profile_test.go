@@ -0,0 +1,87 @@
+package xarrmerchant
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+// memStorage 测试用内存存储
+type memStorage struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{data: map[string][]byte{}}
+}
+
+func (s *memStorage) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key], nil
+}
+
+func (s *memStorage) Set(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *memStorage) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+// TestGroupWhitelist 白名单不依赖默认商户系统，分配商户系统不等于开通
+func TestGroupWhitelist(t *testing.T) {
+	c := NewMerchantClient(newMemStorage())
+
+	// 只配置了非默认商户系统
+	if err := c.SaveProfileConfig("prod", &MerchantConfig{BaseURL: "http://127.0.0.1:1", Secret: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AssignGroup(100, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if c.IsGroupAllowed(100) {
+		t.Error("assigned group allowed without being whitelisted")
+	}
+
+	if err := c.SaveAllowedGroups([]int64{100, 200}); err != nil {
+		t.Fatalf("SaveAllowedGroups() without default profile: %v", err)
+	}
+	if !c.IsGroupAllowed(100) || !c.IsGroupAllowed(200) || c.IsGroupAllowed(300) {
+		t.Errorf("AllowedGroups() = %v, want [100 200]", c.AllowedGroups())
+	}
+
+	// 切换商户系统不影响白名单
+	if err := c.SetActiveProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsGroupAllowed(200) {
+		t.Error("whitelist changed after switching the active profile")
+	}
+}
+
+// TestGroupWhitelistMigration 旧版本保存在默认商户系统中的白名单继续生效
+func TestGroupWhitelistMigration(t *testing.T) {
+	c := NewMerchantClient(newMemStorage())
+
+	if err := c.SaveProfileConfig(DefaultProfile, &MerchantConfig{Secret: "s", AllowedGroups: []int64{123}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.AllowedGroups(); !slices.Equal(got, []int64{123}) {
+		t.Fatalf("AllowedGroups() = %v, want [123]", got)
+	}
+
+	if err := c.SaveAllowedGroups([]int64{456}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.AllowedGroups(); !slices.Equal(got, []int64{456}) {
+		t.Errorf("AllowedGroups() = %v, want [456]", got)
+	}
+}
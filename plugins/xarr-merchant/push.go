@@ -0,0 +1,134 @@
+package xarrmerchant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// pushConfigKey 主动推送配置存储key
+	pushConfigKey = "merchant:push"
+	// pushTimeout 单条推送请求超时
+	pushTimeout = 10 * time.Second
+)
+
+// errPushNotConfigured 未配置推送接口，无法主动推送
+var errPushNotConfigured = errors.New("未配置主动推送接口，请使用 /设置商户推送 设置")
+
+// PushConfig 主动推送配置
+// 通过 OneBot v11 HTTP API 按QQ号/群号发送消息，不依赖会话中收到的消息，机器人重启后立即可用
+type PushConfig struct {
+	APIURL      string `json:"api_url"`      // OneBot HTTP API 地址，如 http://127.0.0.1:3000，为空表示关闭
+	AccessToken string `json:"access_token"` // OneBot access_token，未设置鉴权时为空
+}
+
+// pushClient 推送请求使用的HTTP客户端
+var pushClient = req.C().SetTimeout(pushTimeout)
+
+// loadPushConfig 读取主动推送配置
+func loadPushConfig() (*PushConfig, error) {
+	config := &PushConfig{}
+
+	data, err := storageDB.Get(pushConfigKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// savePushConfig 保存主动推送配置
+func savePushConfig(config *PushConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return storageDB.Set(pushConfigKey, data)
+}
+
+// parsePushURL 校验OneBot HTTP API地址
+func parsePushURL(apiURL string) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("无效的推送接口地址: %s，示例: http://127.0.0.1:3000", apiURL)
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// callOneBot 调用OneBot HTTP API，status为ok或async视为成功
+func callOneBot(ctx context.Context, action string, body map[string]any) error {
+	config, err := loadPushConfig()
+	if err != nil {
+		return err
+	}
+	if config.APIURL == "" {
+		return errPushNotConfigured
+	}
+
+	r := pushClient.R().SetContext(ctx).SetBodyJsonMarshal(body)
+	if config.AccessToken != "" {
+		r.SetBearerAuthToken(config.AccessToken)
+	}
+
+	resp, err := r.Post(config.APIURL + "/" + action)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("推送接口返回 HTTP %d", resp.StatusCode)
+	}
+
+	data := resp.String()
+	if status := gjson.Get(data, "status").String(); status != "ok" && status != "async" {
+		return fmt.Errorf("推送失败(retcode=%d): %s", gjson.Get(data, "retcode").Int(), gjson.Get(data, "message").String())
+	}
+
+	return nil
+}
+
+// pushPrivate 私聊推送消息，消息按纯文本发送
+func pushPrivate(userID int64, msg string) error {
+	return callOneBot(pluginCtx, "send_private_msg", map[string]any{
+		"user_id":     userID,
+		"message":     msg,
+		"auto_escape": true,
+	})
+}
+
+// pushGroup 群聊推送消息，消息按纯文本发送
+func pushGroup(groupID int64, msg string) error {
+	return callOneBot(pluginCtx, "send_group_msg", map[string]any{
+		"group_id":    groupID,
+		"message":     msg,
+		"auto_escape": true,
+	})
+}
+
+// formatPushState 格式化主动推送配置
+func formatPushState() string {
+	config, err := loadPushConfig()
+	if err != nil || config.APIURL == "" {
+		return "未配置"
+	}
+
+	auth := "无鉴权"
+	if config.AccessToken != "" {
+		auth = "已设置access_token"
+	}
+	return fmt.Sprintf("%s (%s)", config.APIURL, auth)
+}
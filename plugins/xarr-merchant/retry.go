@@ -0,0 +1,196 @@
+package xarrmerchant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultFailureThreshold 连续失败多少次后熔断
+	defaultFailureThreshold = 5
+	// defaultOpenDuration 熔断持续时间，到期后放行一次探测请求
+	defaultOpenDuration = 30 * time.Second
+)
+
+// retryPolicy 重试策略
+type retryPolicy struct {
+	MaxAttempts int           // 最大尝试次数(含首次)
+	BaseDelay   time.Duration // 首次重试的基础等待时间
+	MaxDelay    time.Duration // 单次等待上限
+}
+
+var (
+	// defaultRetryPolicy 查询接口默认重试策略
+	defaultRetryPolicy = retryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   300 * time.Millisecond,
+		MaxDelay:    3 * time.Second,
+	}
+	// noRetryPolicy 非幂等接口只请求一次
+	noRetryPolicy = retryPolicy{MaxAttempts: 1}
+)
+
+// backoff 计算第attempt次失败后的等待时间
+// 指数退避，取[d/2, d]之间的随机值避免多个请求同时重试
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// sleepContext 等待指定时间，context取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isUpstreamFailure 判断错误是否由商户系统不可用导致
+// 网络错误、请求超时、5xx及非JSON响应视为上游故障；业务错误和主动取消不算
+func isUpstreamFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrResponseSignature) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus >= 500
+	}
+
+	return true
+}
+
+// wrapUpstream 将网络异常等上游故障包装为 ErrUpstream，便于调用方统一判断
+func wrapUpstream(err error) error {
+	if !isUpstreamFailure(err) || errors.Is(err, ErrUpstream) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrUpstream, err)
+}
+
+// 熔断器状态
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// BreakerState 熔断器状态快照
+type BreakerState struct {
+	State     string    // closed/open/half-open
+	Failures  int       // 连续失败次数
+	OpenedAt  time.Time // 最近一次熔断时间
+	RetryAt   time.Time // 允许探测的时间
+	LastError time.Time // 最近一次失败时间
+}
+
+// circuitBreaker 熔断器
+// 连续失败达到阈值后熔断，熔断期间直接拒绝请求；到期后放行一个探测请求，成功则恢复
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	duration  time.Duration
+
+	current   string
+	failures  int
+	openedAt  time.Time
+	lastError time.Time
+	probing   bool
+}
+
+// newCircuitBreaker 创建熔断器
+func newCircuitBreaker(threshold int, duration time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		duration:  duration,
+		current:   breakerClosed,
+	}
+}
+
+// allow 判断是否允许发送请求
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.current {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.duration {
+			return ErrCircuitOpen
+		}
+		// 熔断到期，进入半开状态放行一个探测请求
+		b.current = breakerHalfOpen
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record 记录请求结果
+// 主动取消的请求无法说明商户系统是否可用，只释放探测名额，不改变状态
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if !isUpstreamFailure(err) {
+		b.current = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	b.lastError = time.Now()
+
+	// 半开探测失败或连续失败达到阈值时熔断
+	if b.current == breakerHalfOpen || b.failures >= b.threshold {
+		b.current = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// state 获取状态快照
+func (b *circuitBreaker) state() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := BreakerState{
+		State:     b.current,
+		Failures:  b.failures,
+		OpenedAt:  b.openedAt,
+		LastError: b.lastError,
+	}
+	if b.current == breakerOpen {
+		state.RetryAt = b.openedAt.Add(b.duration)
+	}
+
+	return state
+}
@@ -0,0 +1,160 @@
+package xarrmerchant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker 校验熔断器在 closed → open → half-open → closed/open 之间的转换
+func TestCircuitBreaker(t *testing.T) {
+	errNetwork := errors.New("connection refused")
+	errBusiness := newAPIError("/test", 400, "参数错误", "", 200)
+
+	// step 一次操作及操作后的期望状态
+	type step struct {
+		expire    bool  // 操作前令熔断到期
+		allowErr  error // allow 的期望返回值
+		result    error // 允许时记录的请求结果
+		wantState string
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "连续失败达到阈值后熔断",
+			steps: []step{
+				{result: errNetwork, wantState: breakerClosed},
+				{result: errNetwork, wantState: breakerOpen},
+				{allowErr: ErrCircuitOpen, wantState: breakerOpen},
+			},
+		},
+		{
+			name: "成功重置连续失败次数",
+			steps: []step{
+				{result: errNetwork, wantState: breakerClosed},
+				{result: nil, wantState: breakerClosed},
+				{result: errNetwork, wantState: breakerClosed},
+			},
+		},
+		{
+			name: "业务错误不计入失败",
+			steps: []step{
+				{result: errNetwork, wantState: breakerClosed},
+				{result: errBusiness, wantState: breakerClosed},
+				{result: errNetwork, wantState: breakerClosed},
+			},
+		},
+		{
+			name: "半开探测成功后恢复",
+			steps: []step{
+				{result: errNetwork, wantState: breakerClosed},
+				{result: errNetwork, wantState: breakerOpen},
+				{expire: true, result: nil, wantState: breakerClosed},
+				{result: nil, wantState: breakerClosed},
+			},
+		},
+		{
+			name: "半开探测失败后重新熔断",
+			steps: []step{
+				{result: errNetwork, wantState: breakerClosed},
+				{result: errNetwork, wantState: breakerOpen},
+				{expire: true, result: errNetwork, wantState: breakerOpen},
+				{allowErr: ErrCircuitOpen, wantState: breakerOpen},
+			},
+		},
+		{
+			name: "半开探测被取消时保持半开并释放探测名额",
+			steps: []step{
+				{result: errNetwork, wantState: breakerClosed},
+				{result: errNetwork, wantState: breakerOpen},
+				{expire: true, result: context.Canceled, wantState: breakerHalfOpen},
+				{result: nil, wantState: breakerClosed},
+			},
+		},
+		{
+			name: "取消的请求不影响关闭状态",
+			steps: []step{
+				{result: errNetwork, wantState: breakerClosed},
+				{result: context.Canceled, wantState: breakerClosed},
+				{result: errNetwork, wantState: breakerOpen},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker(2, time.Minute)
+
+			for i, s := range tt.steps {
+				if s.expire {
+					b.mu.Lock()
+					b.openedAt = time.Now().Add(-b.duration)
+					b.mu.Unlock()
+				}
+
+				err := b.allow()
+				if !errors.Is(err, s.allowErr) {
+					t.Fatalf("step %d: allow() = %v, want %v", i, err, s.allowErr)
+				}
+				if err == nil {
+					b.record(s.result)
+				}
+
+				if got := b.state().State; got != s.wantState {
+					t.Fatalf("step %d: state = %s, want %s", i, got, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+// TestCircuitBreakerSingleProbe 半开状态下只放行一个探测请求
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	b := newCircuitBreaker(1, time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() = %v", err)
+	}
+	b.record(errors.New("timeout"))
+
+	b.openedAt = time.Now().Add(-b.duration)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe allow() = %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second allow() = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+// TestBackoff 校验退避时间在 [d/2, d] 之间且不超过上限
+func TestBackoff(t *testing.T) {
+	policy := retryPolicy{
+		MaxAttempts: 10,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    time.Second,
+	}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{64, time.Second}, // 移位溢出时使用上限
+	}
+
+	for _, tt := range tests {
+		for range 100 {
+			got := policy.backoff(tt.attempt)
+			if got < tt.max/2 || got > tt.max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempt, got, tt.max/2, tt.max)
+			}
+		}
+	}
+}
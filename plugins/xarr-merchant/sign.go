@@ -0,0 +1,156 @@
+package xarrmerchant
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// 签名算法
+const (
+	SignTypeMD5        = "md5"
+	SignTypeSHA256     = "sha256"
+	SignTypeHMACSHA256 = "hmac-sha256"
+)
+
+// Signer 签名算法接口
+// content 为按key排序拼接后的参数串，不含sign字段
+type Signer interface {
+	Sign(content, secret string) string
+}
+
+// md5Signer sign = md5(content + secret)
+type md5Signer struct{}
+
+func (md5Signer) Sign(content, secret string) string {
+	hash := md5.Sum([]byte(content + secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// sha256Signer sign = sha256(content + secret)
+type sha256Signer struct{}
+
+func (sha256Signer) Sign(content, secret string) string {
+	hash := sha256.Sum256([]byte(content + secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// hmacSHA256Signer sign = hmac_sha256(key=secret, content)
+type hmacSHA256Signer struct{}
+
+func (hmacSHA256Signer) Sign(content, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signers 已支持的签名算法
+var signers = map[string]Signer{
+	SignTypeMD5:        md5Signer{},
+	SignTypeSHA256:     sha256Signer{},
+	SignTypeHMACSHA256: hmacSHA256Signer{},
+}
+
+// normalizeSignType 规范化签名算法名称，空值为md5
+func normalizeSignType(signType string) (string, error) {
+	signType = strings.ToLower(strings.TrimSpace(signType))
+	if signType == "" {
+		return SignTypeMD5, nil
+	}
+	if _, ok := signers[signType]; !ok {
+		return "", fmt.Errorf("不支持的签名算法: %s (可选: md5, sha256, hmac-sha256)", signType)
+	}
+	return signType, nil
+}
+
+// getSigner 获取签名算法，未知或未设置时使用md5
+func getSigner(signType string) Signer {
+	if signer, ok := signers[strings.ToLower(signType)]; ok {
+		return signer
+	}
+	return md5Signer{}
+}
+
+// generateNonce 生成32位十六进制随机串
+func generateNonce() string {
+	buf := make([]byte, 16)
+	// crypto/rand.Read 不会返回错误
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// canonicalize 参数按key排序后以 k=v&k=v 拼接，排除sign字段本身
+func canonicalize(params map[string]string) string {
+	// 获取所有key并排序
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	// 拼接参数
+	var builder strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			builder.WriteString("&")
+		}
+		builder.WriteString(k)
+		builder.WriteString("=")
+		builder.WriteString(params[k])
+	}
+
+	return builder.String()
+}
+
+// signParams 使用指定算法对参数签名
+func signParams(params map[string]string, secret, signType string) string {
+	return getSigner(signType).Sign(canonicalize(params), secret)
+}
+
+// generateSign 生成签名
+// sign = md5(参数按key排序后拼接 + secret)
+func generateSign(params map[string]string, secret string) string {
+	return signParams(params, secret, SignTypeMD5)
+}
+
+// responseSignParams 提取响应顶层字段用于验签
+// 字符串取原值，其余类型(数字、对象、数组等)取原始JSON文本
+func responseSignParams(body string) map[string]string {
+	params := map[string]string{}
+	gjson.Parse(body).ForEach(func(key, value gjson.Result) bool {
+		if value.Type == gjson.String {
+			params[key.String()] = value.String()
+		} else {
+			params[key.String()] = value.Raw
+		}
+		return true
+	})
+	return params
+}
+
+// verifyResponseSign 校验响应签名，签名规则与请求签名一致
+func verifyResponseSign(body, secret, signType string) error {
+	params := responseSignParams(body)
+
+	sign, ok := params["sign"]
+	if !ok || sign == "" {
+		return fmt.Errorf("%w: 响应未签名", ErrResponseSignature)
+	}
+
+	expected := signParams(params, secret, signType)
+	if subtle.ConstantTimeCompare([]byte(strings.ToLower(sign)), []byte(expected)) != 1 {
+		return fmt.Errorf("%w: 签名不匹配", ErrResponseSignature)
+	}
+
+	return nil
+}
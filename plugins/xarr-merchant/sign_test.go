@@ -0,0 +1,42 @@
+package xarrmerchant
+
+import "testing"
+
+// TestSignParams 校验README中公布的签名测试向量
+func TestSignParams(t *testing.T) {
+	params := map[string]string{
+		"open_id":      "123456",
+		"connect_type": "xbot",
+		"timestamp":    "1700000000",
+	}
+	const secret = "test_secret"
+
+	if got, want := canonicalize(params), "connect_type=xbot&open_id=123456&timestamp=1700000000"; got != want {
+		t.Fatalf("canonicalize() = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		signType string
+		want     string
+	}{
+		{SignTypeMD5, "0d67a300ee4b29dc671ce8c369ad8b23"},
+		{SignTypeSHA256, "1b7f758beba2d4f9e4ed3682056242cc7204b12750431a355cc410a16bf7a664"},
+		{SignTypeHMACSHA256, "49d8bcdfd50f3339619f89c6776ec5261d4b3d73415d4f28ec5030507a40394e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.signType, func(t *testing.T) {
+			if got := signParams(params, secret, tt.signType); got != tt.want {
+				t.Errorf("signParams(%s) = %s, want %s", tt.signType, got, tt.want)
+			}
+		})
+	}
+
+	// generateSign 固定使用md5，未知算法回退到md5
+	if got := generateSign(params, secret); got != tests[0].want {
+		t.Errorf("generateSign() = %s, want %s", got, tests[0].want)
+	}
+	if got := signParams(params, secret, "unknown"); got != tests[0].want {
+		t.Errorf("signParams(unknown) = %s, want %s", got, tests[0].want)
+	}
+}
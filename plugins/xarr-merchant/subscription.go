@@ -0,0 +1,209 @@
+package xarrmerchant
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// subscriptionsKey 通知订阅存储key
+const subscriptionsKey = "merchant:subscriptions"
+
+// NotifyTarget 群聊到账通知对应的商户账号
+// 群聊可能分配了与私聊不同的商户系统，每个群单独记录
+type NotifyTarget struct {
+	Profile string `json:"profile"` // 开启时群聊使用的商户系统
+	UID     int64  `json:"uid"`     // 该商户系统中的用户UID
+}
+
+// Subscription 用户的通知订阅设置
+type Subscription struct {
+	UserID  int64  `json:"user_id"` // QQ号
+	Profile string `json:"profile"` // 私聊功能使用的商户系统，首次订阅时确定，之后不再改变
+	UID     int64  `json:"uid"`     // Profile 中的用户UID，用于匹配私聊到账通知
+	Private bool   `json:"private"` // 私聊推送到账通知
+
+	GroupTargets map[int64]NotifyTarget `json:"group_targets"`    // 推送到账通知的群聊
+	Groups       []int64                `json:"groups,omitempty"` // 旧版本的群聊列表，读取时迁移到GroupTargets
+
+	ChannelAlert    bool  `json:"channel_alert"`    // 渠道离线/禁用告警
+	LimitAlert      bool  `json:"limit_alert"`      // 额度预警
+	LimitThresholds []int `json:"limit_thresholds"` // 额度预警阈值(百分比)，为空时使用默认值
+
+	ExpiryReminderOff  bool  `json:"expiry_reminder_off"`  // 关闭套餐到期提醒，默认开启
+	ExpiryReminderDays []int `json:"expiry_reminder_days"` // 到期前提醒天数，为空时使用默认值
+}
+
+// subscriptionsMu 保护订阅数据的读写
+var subscriptionsMu sync.Mutex
+
+// loadSubscriptions 读取所有订阅，调用方需持有subscriptionsMu
+func loadSubscriptions() (map[int64]*Subscription, error) {
+	subs := map[int64]*Subscription{}
+
+	data, err := storageDB.Get(subscriptionsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		if err := json.Unmarshal(data, &subs); err != nil {
+			return nil, err
+		}
+	}
+
+	// 旧版本群聊共用订阅的商户系统和UID
+	for _, sub := range subs {
+		if len(sub.Groups) == 0 {
+			continue
+		}
+		if sub.GroupTargets == nil {
+			sub.GroupTargets = map[int64]NotifyTarget{}
+		}
+		for _, groupID := range sub.Groups {
+			if _, ok := sub.GroupTargets[groupID]; !ok {
+				sub.GroupTargets[groupID] = NotifyTarget{Profile: sub.Profile, UID: sub.UID}
+			}
+		}
+		sub.Groups = nil
+	}
+
+	return subs, nil
+}
+
+// saveSubscriptions 保存所有订阅，调用方需持有subscriptionsMu
+func saveSubscriptions(subs map[int64]*Subscription) error {
+	data, err := json.Marshal(subs)
+	if err != nil {
+		return err
+	}
+	return storageDB.Set(subscriptionsKey, data)
+}
+
+// getSubscription 获取用户的订阅设置，未订阅时返回nil
+func getSubscription(userID int64) (*Subscription, error) {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	subs, err := loadSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return subs[userID], nil
+}
+
+// updateSubscription 修改用户的订阅设置，不存在时自动创建
+func updateSubscription(userID int64, update func(sub *Subscription)) error {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	subs, err := loadSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	sub, ok := subs[userID]
+	if !ok {
+		sub = &Subscription{UserID: userID}
+		subs[userID] = sub
+	}
+	update(sub)
+
+	return saveSubscriptions(subs)
+}
+
+// removeSubscription 删除用户的全部订阅及巡检状态，用于解绑
+func removeSubscription(userID int64) error {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	subs, err := loadSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range []string{channelStateKey(userID), limitStateKey(userID), expiryStateKey(userID)} {
+		if err := storageDB.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := subs[userID]; !ok {
+		return nil
+	}
+	delete(subs, userID)
+
+	return saveSubscriptions(subs)
+}
+
+// listSubscriptions 获取所有订阅，按QQ号升序排列
+func listSubscriptions() ([]Subscription, error) {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	subs, err := loadSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Subscription, 0, len(subs))
+	for _, sub := range subs {
+		result = append(result, *sub)
+	}
+	slices.SortFunc(result, func(a, b Subscription) int {
+		return cmp.Compare(a.UserID, b.UserID)
+	})
+
+	return result, nil
+}
+
+// privateProfileOf 私聊功能使用的商户系统
+// 已订阅时沿用订阅中的商户系统，保证与已保存的UID一致，并使巡检始终查询同一商户系统
+func privateProfileOf(userID int64) string {
+	if sub, err := getSubscription(userID); err == nil && sub != nil && sub.Profile != "" {
+		return sub.Profile
+	}
+	return client.ResolveProfile(userID, 0)
+}
+
+// notifiesPrivate 用户是否订阅了指定商户的私聊到账通知
+func (sub Subscription) notifiesPrivate(profile string, uid int64) bool {
+	return sub.Private && sub.Profile == profile && sub.UID == uid
+}
+
+// notifiedGroups 用户订阅了指定商户到账通知的群聊，按群号升序排列
+func (sub Subscription) notifiedGroups(profile string, uid int64) []int64 {
+	var groups []int64
+	for groupID, target := range sub.GroupTargets {
+		if target.Profile == profile && target.UID == uid {
+			groups = append(groups, groupID)
+		}
+	}
+	slices.Sort(groups)
+	return groups
+}
+
+// subscribersOf 获取订阅了指定商户到账通知的用户，按QQ号升序排列
+func subscribersOf(profile string, uid int64) ([]Subscription, error) {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	subs, err := loadSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Subscription
+	for _, sub := range subs {
+		if sub.notifiesPrivate(profile, uid) || len(sub.notifiedGroups(profile, uid)) > 0 {
+			result = append(result, *sub)
+		}
+	}
+	slices.SortFunc(result, func(a, b Subscription) int {
+		return cmp.Compare(a.UserID, b.UserID)
+	})
+
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package xarrmerchant
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestSubscribersOfPerTarget 私聊和各群聊分别匹配各自的商户系统和UID
+func TestSubscribersOfPerTarget(t *testing.T) {
+	const userID = 30001
+	t.Cleanup(func() { removeSubscription(userID) })
+
+	// 在分配了商户系统B的群开启到账通知，之后在私聊中开启默认商户系统的功能
+	err := updateSubscription(userID, func(sub *Subscription) {
+		sub.GroupTargets = map[int64]NotifyTarget{500: {Profile: "B", UID: 7}}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = updateSubscription(userID, func(sub *Subscription) {
+		sub.Profile = DefaultProfile
+		sub.UID = 9
+		sub.Private = true
+		sub.ChannelAlert = true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subs, err := subscribersOf("B", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 {
+		t.Fatalf("subscribersOf(B) = %d subs, want 1", len(subs))
+	}
+	if subs[0].notifiesPrivate("B", 7) {
+		t.Error("private chat notified for profile B")
+	}
+	if got := subs[0].notifiedGroups("B", 7); !slices.Equal(got, []int64{500}) {
+		t.Errorf("notifiedGroups(B) = %v, want [500]", got)
+	}
+
+	subs, err = subscribersOf(DefaultProfile, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || !subs[0].notifiesPrivate(DefaultProfile, 9) || len(subs[0].notifiedGroups(DefaultProfile, 9)) != 0 {
+		t.Errorf("subscribersOf(default) = %+v, want private only", subs)
+	}
+}
+
+// TestLoadSubscriptionsMigratesGroups 旧版本的群聊列表迁移为按群记录的商户系统
+func TestLoadSubscriptionsMigratesGroups(t *testing.T) {
+	const userID = 30002
+	t.Cleanup(func() { removeSubscription(userID) })
+
+	subscriptionsMu.Lock()
+	err := storageDB.Set(subscriptionsKey, []byte(`{"30002":{"user_id":30002,"profile":"B","uid":7,"groups":[500,600]}}`))
+	subscriptionsMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := getSubscription(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Groups != nil {
+		t.Errorf("Groups = %v, want nil after migration", sub.Groups)
+	}
+	if got := sub.notifiedGroups("B", 7); !slices.Equal(got, []int64{500, 600}) {
+		t.Errorf("notifiedGroups(B) = %v, want [500 600]", got)
+	}
+}
@@ -0,0 +1,142 @@
+package xarrmerchant
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/imroc/req/v3"
+	"github.com/xiaoyi510/xbot/logger"
+)
+
+// tlsVersions 支持设置的TLS最低版本
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// transportKey 连接相关配置的指纹，变化时需要重建HTTP客户端
+func (config *MerchantConfig) transportKey() string {
+	return strings.Join([]string{
+		config.Proxy,
+		config.CACertFile,
+		config.ClientCertFile,
+		config.ClientKeyFile,
+		config.TLSMinVersion,
+		fmt.Sprint(config.InsecureSkipVerify),
+	}, "|")
+}
+
+// parseProxyURL 解析代理地址，支持 http/https/socks5
+func parseProxyURL(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("无效的代理地址: %s", proxy)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s (可选: http, https, socks5)", u.Scheme)
+	}
+}
+
+// buildHTTPClient 根据配置创建HTTP客户端
+// 证书文件读取失败时直接返回错误，避免静默退回默认设置
+func buildHTTPClient(config *MerchantConfig) (*req.Client, error) {
+	c := req.C().SetTimeout(maxClientTimeout)
+
+	if config.Proxy != "" {
+		u, err := parseProxyURL(config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		c.SetProxy(http.ProxyURL(u))
+	}
+
+	tlsConfig := c.GetTLSClientConfig()
+
+	if config.CACertFile != "" {
+		pem, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取根证书失败: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("根证书文件中没有有效的PEM证书")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if config.TLSMinVersion != "" {
+		version, ok := tlsVersions[config.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("不支持的TLS版本: %s (可选: 1.0, 1.1, 1.2, 1.3)", config.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if config.InsecureSkipVerify {
+		logger.Warn("商户系统 " + config.BaseURL + " 已跳过证书验证，连接存在被中间人攻击的风险")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return c, nil
+}
+
+// httpClient 获取商户系统的HTTP客户端，连接配置变化时重建
+func (b *backend) httpClient(config *MerchantConfig) (*req.Client, error) {
+	b.httpMu.Lock()
+	defer b.httpMu.Unlock()
+
+	key := config.transportKey()
+	if b.http != nil && b.httpKey == key {
+		return b.http, nil
+	}
+
+	c, err := buildHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	b.http = c
+	b.httpKey = key
+	return c, nil
+}
+
+// resetHTTPClient 丢弃已构建的HTTP客户端，下次请求时重新构建
+func (b *backend) resetHTTPClient() {
+	b.httpMu.Lock()
+	defer b.httpMu.Unlock()
+
+	b.http = nil
+	b.httpKey = ""
+}
+
+// redactProxy 隐藏代理地址中的密码
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return proxy
+	}
+	return u.Redacted()
+}
@@ -2,9 +2,22 @@ package xarrmerchant
 
 // MerchantConfig 商户配置
 type MerchantConfig struct {
-	BaseURL       string  `json:"base_url"`       // API基础地址
-	Secret        string  `json:"secret"`         // API密钥
-	AllowedGroups []int64 `json:"allowed_groups"` // 允许使用的群聊列表
+	BaseURL        string  `json:"base_url"`        // API基础地址
+	Secret         string  `json:"secret"`          // API密钥
+	SignType       string  `json:"sign_type"`       // 签名算法: md5(默认)/sha256/hmac-sha256
+	VerifyResponse bool    `json:"verify_response"` // 是否校验响应签名
+	EnableNonce    bool    `json:"enable_nonce"`    // 是否在请求中附加随机串nonce
+	AllowedGroups  []int64 `json:"allowed_groups"`  // 旧版本的群聊白名单，仅默认商户系统使用，已迁移到商户系统索引
+
+	Timeout         int            `json:"timeout"`          // 全局请求超时(秒)，0表示使用默认值
+	RequestTimeouts map[string]int `json:"request_timeouts"` // 按接口路径单独设置的超时(秒)
+
+	Proxy              string `json:"proxy"`                // 代理地址，支持 http/https/socks5
+	CACertFile         string `json:"ca_cert_file"`         // 自定义根证书(PEM)路径
+	ClientCertFile     string `json:"client_cert_file"`     // 客户端证书路径(mTLS)
+	ClientKeyFile      string `json:"client_key_file"`      // 客户端私钥路径(mTLS)
+	TLSMinVersion      string `json:"tls_min_version"`      // TLS最低版本: 1.0/1.1/1.2/1.3
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"` // 跳过证书验证，仅用于调试
 }
 
 // UserInfo 用户信息
@@ -18,6 +31,7 @@ type UserInfo struct {
 
 // UserMealInfo 用户套餐信息
 type UserMealInfo struct {
+	MealID              int64  `json:"meal_id"`               // 套餐ID，旧版接口可能不返回
 	MealName            string `json:"meal_name"`             // 套餐名称
 	ExpireTime          int64  `json:"expire_time"`           // 到期时间
 	ChannelAccountCount int    `json:"channel_account_count"` // 通道账号可添加数
@@ -26,6 +40,27 @@ type UserMealInfo struct {
 	Rate                int    `json:"rate"`                  // 费率(分)
 }
 
+// Meal 可购买的套餐
+type Meal struct {
+	ID                  int64  `json:"id"`
+	Name                string `json:"name"`                  // 套餐名称
+	Price               int64  `json:"price"`                 // 价格(分)
+	Days                int    `json:"days"`                  // 有效天数，-1表示永久
+	ChannelAccountCount int    `json:"channel_account_count"` // 通道账号可添加数
+	DayLimit            int64  `json:"day_limit"`             // 每日收款限额(分)
+	MonthLimit          int64  `json:"month_limit"`           // 每月收款限额(分)
+	Rate                int    `json:"rate"`                  // 费率(分)
+	Remark              string `json:"remark"`                // 套餐说明
+}
+
+// MealPurchaseResult 购买/续费套餐结果
+type MealPurchaseResult struct {
+	MealName   string `json:"meal_name"`   // 套餐名称
+	Amount     int64  `json:"amount"`      // 扣款金额(分)
+	Balance    int64  `json:"balance"`     // 扣款后余额(分)
+	ExpireTime int64  `json:"expire_time"` // 新的到期时间，-1表示永久
+}
+
 // UserPayStat 用户支付统计
 type UserPayStat struct {
 	TodayAmount     int64 `json:"today_amount"`      // 今日支付金额(分)
@@ -49,3 +84,201 @@ type ChannelAccount struct {
 	DayAmount      int64  `json:"day_amount"`       // 今日已支付额度(分)
 	DayAmountLimit int64  `json:"day_amount_limit"` // 单日限额(分)
 }
+
+// 渠道账户状态
+const (
+	ChannelStatusDisabled = 0 // 禁用
+	ChannelStatusEnabled  = 1 // 启用
+)
+
+// 渠道账户在线状态
+const (
+	ChannelOffline = 0 // 离线
+	ChannelOnline  = 1 // 在线
+)
+
+// ChannelOnlineLog 渠道账户上下线记录
+type ChannelOnlineLog struct {
+	Online int   `json:"online"` // 变更后的在线状态
+	Time   int64 `json:"time"`   // 变更时间
+}
+
+// ChannelAccountDetail 渠道账户详情
+type ChannelAccountDetail struct {
+	ChannelAccount
+	LastHeartbeat     int64              `json:"last_heartbeat"`      // 最后心跳时间
+	TodayOrderCount   int64              `json:"today_order_count"`   // 今日订单数
+	TodaySuccessCount int64              `json:"today_success_count"` // 今日支付成功订单数
+	OnlineLogs        []ChannelOnlineLog `json:"online_logs"`         // 最近上下线记录
+	RecentOrders      []Order            `json:"recent_orders"`       // 最近订单
+}
+
+// SuccessRate 今日支付成功率(百分比)，无订单时返回-1
+func (d *ChannelAccountDetail) SuccessRate() float64 {
+	if d.TodayOrderCount <= 0 {
+		return -1
+	}
+	return float64(d.TodaySuccessCount) * 100 / float64(d.TodayOrderCount)
+}
+
+// 订单状态
+const (
+	OrderStatusUnpaid   = 0 // 未支付
+	OrderStatusPaid     = 1 // 已支付
+	OrderStatusClosed   = 2 // 已关闭
+	OrderStatusRefunded = 3 // 已退款
+)
+
+// 通知状态
+const (
+	NotifyStatusPending = 0 // 未通知
+	NotifyStatusSuccess = 1 // 通知成功
+	NotifyStatusFailed  = 2 // 通知失败
+)
+
+// Order 订单信息
+type Order struct {
+	TradeNo            string `json:"trade_no"`             // 平台订单号
+	OutTradeNo         string `json:"out_trade_no"`         // 商户订单号
+	Name               string `json:"name"`                 // 商品名称
+	Amount             int64  `json:"amount"`               // 订单金额(分)
+	RealAmount         int64  `json:"real_amount"`          // 实付金额(分)
+	RefundAmount       int64  `json:"refund_amount"`        // 已退款金额(分)
+	PayType            string `json:"pay_type"`             // 支付类型
+	PayTypeName        string `json:"pay_type_name"`        // 支付方式名称
+	ChannelAccountID   int64  `json:"channel_account_id"`   // 渠道账户ID
+	ChannelAccountName string `json:"channel_account_name"` // 渠道账户名称
+	Status             int    `json:"status"`               // 订单状态
+	NotifyStatus       int    `json:"notify_status"`        // 通知状态
+	CreateTime         int64  `json:"create_time"`          // 创建时间
+	PayTime            int64  `json:"pay_time"`             // 支付时间
+}
+
+// OrderFilter 订单列表筛选条件，零值表示不筛选
+type OrderFilter struct {
+	Status           *int   // 订单状态
+	PayType          string // 支付类型
+	ChannelAccountID int64  // 渠道账户ID
+	StartTime        int64  // 创建时间起始(含)
+	EndTime          int64  // 创建时间截止(含)
+	MinAmount        int64  // 最小金额(分)
+	MaxAmount        int64  // 最大金额(分)
+	Page             int    // 页码，从1开始
+	PageSize         int    // 每页数量
+}
+
+// OrderPage 订单分页结果
+type OrderPage struct {
+	List     []Order `json:"list"`
+	Total    int64   `json:"total"`     // 总数量
+	Page     int     `json:"page"`      // 当前页码
+	PageSize int     `json:"page_size"` // 每页数量
+}
+
+// TotalPages 总页数
+func (p *OrderPage) TotalPages() int {
+	return totalPages(p.Total, p.PageSize)
+}
+
+// totalPages 根据总数量和每页数量计算总页数，至少为1
+func totalPages(total int64, pageSize int) int {
+	if pageSize <= 0 {
+		return 1
+	}
+	pages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if pages < 1 {
+		return 1
+	}
+	return pages
+}
+
+// NotifyResult 重发通知结果
+type NotifyResult struct {
+	Success    bool   `json:"success"`     // 商户是否返回success
+	HTTPStatus int    `json:"http_status"` // 商户通知地址返回的HTTP状态码
+	Response   string `json:"response"`    // 商户通知地址返回的内容
+	Duration   int64  `json:"duration"`    // 请求耗时(毫秒)
+	Error      string `json:"error"`       // 请求失败原因，如连接超时
+}
+
+// 退款状态
+const (
+	RefundStatusProcessing = 0 // 处理中
+	RefundStatusSuccess    = 1 // 退款成功
+	RefundStatusFailed     = 2 // 退款失败
+)
+
+// RefundResult 退款结果
+type RefundResult struct {
+	RefundNo string `json:"refund_no"` // 退款单号
+	Amount   int64  `json:"amount"`    // 退款金额(分)
+	Status   int    `json:"status"`    // 退款状态
+	Message  string `json:"message"`   // 渠道返回的说明
+}
+
+// CreateOrderRequest 创建收款订单参数
+type CreateOrderRequest struct {
+	Amount  int64  // 订单金额(分)
+	Name    string // 商品名称/备注
+	PayType string // 支付类型，为空时由商户系统决定
+}
+
+// PaymentOrder 创建订单返回的支付信息
+type PaymentOrder struct {
+	TradeNo    string `json:"trade_no"`     // 平台订单号
+	OutTradeNo string `json:"out_trade_no"` // 商户订单号
+	Amount     int64  `json:"amount"`       // 订单金额(分)
+	PayType    string `json:"pay_type"`     // 支付类型
+	PayURL     string `json:"pay_url"`      // 支付链接
+	QRCode     string `json:"qrcode"`       // 二维码内容，为空时使用支付链接
+	ExpireTime int64  `json:"expire_time"`  // 订单过期时间
+}
+
+// 充值订单状态
+const (
+	RechargeStatusUnpaid   = 0 // 待支付
+	RechargeStatusCredited = 1 // 已到账
+	RechargeStatusClosed   = 2 // 已关闭
+)
+
+// RechargeOrder 余额充值订单
+type RechargeOrder struct {
+	TradeNo string `json:"trade_no"` // 充值订单号
+	Amount  int64  `json:"amount"`   // 充值金额(分)
+	Status  int    `json:"status"`   // 充值状态
+	Balance int64  `json:"balance"`  // 到账后余额(分)，未返回时为0
+	PayTime int64  `json:"pay_time"` // 到账时间
+}
+
+// BalanceLog 资金流水
+type BalanceLog struct {
+	ID         int64  `json:"id"`
+	Type       int    `json:"type"`        // 变动类型
+	TypeName   string `json:"type_name"`   // 变动类型名称，如 充值、手续费
+	Amount     int64  `json:"amount"`      // 变动金额(分)，支出为负数
+	Before     int64  `json:"before"`      // 变动前余额(分)
+	After      int64  `json:"after"`       // 变动后余额(分)
+	Remark     string `json:"remark"`      // 备注
+	CreateTime int64  `json:"create_time"` // 变动时间
+}
+
+// BalanceLogFilter 资金流水筛选条件，零值表示不筛选
+type BalanceLogFilter struct {
+	StartTime int64 // 起始时间(含)
+	EndTime   int64 // 截止时间(含)
+	Page      int   // 页码，从1开始
+	PageSize  int   // 每页数量
+}
+
+// BalanceLogPage 资金流水分页结果
+type BalanceLogPage struct {
+	List     []BalanceLog `json:"list"`
+	Total    int64        `json:"total"`     // 总数量
+	Page     int          `json:"page"`      // 当前页码
+	PageSize int          `json:"page_size"` // 每页数量
+}
+
+// TotalPages 总页数
+func (p *BalanceLogPage) TotalPages() int {
+	return totalPages(p.Total, p.PageSize)
+}
@@ -1,7 +1,10 @@
 package xarrmerchant
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +15,99 @@ func formatAmount(amount int64) string {
 	return fmt.Sprintf("%.2f", float64(amount)/100)
 }
 
+// parseAmount 解析金额(元转分)，最多两位小数
+func parseAmount(yuan string) (int64, error) {
+	yuan = strings.TrimPrefix(strings.TrimSpace(yuan), "¥")
+
+	intPart, fracPart, hasFrac := strings.Cut(yuan, ".")
+	if intPart == "" || (hasFrac && (fracPart == "" || len(fracPart) > 2)) {
+		return 0, fmt.Errorf("无效的金额: %s", yuan)
+	}
+
+	for len(fracPart) < 2 {
+		fracPart += "0"
+	}
+
+	for _, r := range intPart + fracPart {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("无效的金额: %s", yuan)
+		}
+	}
+
+	amount, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的金额: %s", yuan)
+	}
+
+	return amount, nil
+}
+
+// parseDateRange 解析日期范围，返回起止时间戳(含)
+// 支持: 今天、昨天、近7天、本月、2006-01-02、2006-01-02~2006-01-31
+func parseDateRange(text string) (int64, int64, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	var start, end time.Time
+	switch text {
+	case "今天", "今日":
+		start, end = today, today
+	case "昨天", "昨日":
+		start = today.AddDate(0, 0, -1)
+		end = start
+	case "近7天", "最近7天":
+		start, end = today.AddDate(0, 0, -6), today
+	case "本月":
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		end = today
+	default:
+		from, to, isRange := strings.Cut(text, "~")
+		if !isRange {
+			to = from
+		}
+
+		var err error
+		start, err = time.ParseInLocation("2006-01-02", strings.TrimSpace(from), time.Local)
+		if err != nil {
+			return 0, 0, fmt.Errorf("无效的日期: %s", from)
+		}
+		end, err = time.ParseInLocation("2006-01-02", strings.TrimSpace(to), time.Local)
+		if err != nil {
+			return 0, 0, fmt.Errorf("无效的日期: %s", to)
+		}
+	}
+
+	if end.Before(start) {
+		return 0, 0, errors.New("结束日期不能早于开始日期")
+	}
+
+	// 截止到结束日期当天最后一秒
+	return start.Unix(), end.AddDate(0, 0, 1).Unix() - 1, nil
+}
+
+// formatSignedAmount 格式化带正负号的金额(分转元)
+func formatSignedAmount(amount int64) string {
+	if amount < 0 {
+		return "-" + formatAmount(-amount)
+	}
+	return "+" + formatAmount(amount)
+}
+
+// formatDuration 格式化时长，精确到分钟
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 1 {
+		return "不到1分钟"
+	}
+	if minutes < 60 {
+		return fmt.Sprintf("%d分钟", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d小时", minutes/60)
+	}
+	return fmt.Sprintf("%d小时%d分钟", minutes/60, minutes%60)
+}
+
 // formatTime 格式化时间
 func formatTime(timestamp int64) string {
 	if timestamp == 0 {
@@ -71,6 +167,275 @@ func formatGroupIDs(groups []int64) string {
 	return strings.Join(strs, ", ")
 }
 
+// formatSignType 格式化签名算法
+func formatSignType(signType string) string {
+	if signType == "" {
+		return SignTypeMD5 + " (默认)"
+	}
+	return signType
+}
+
+// formatSwitch 格式化开关状态
+func formatSwitch(enabled bool) string {
+	if enabled {
+		return "已开启"
+	}
+	return "已关闭"
+}
+
+// formatTimeout 格式化超时时间(秒)，0表示使用默认值
+func formatTimeout(seconds int) string {
+	if seconds <= 0 {
+		return fmt.Sprintf("默认(%d秒)", int(DefaultTimeout/time.Second))
+	}
+	return fmt.Sprintf("%d秒", seconds)
+}
+
+// formatRequestTimeouts 格式化按接口设置的超时
+func formatRequestTimeouts(timeouts map[string]int) string {
+	if len(timeouts) == 0 {
+		return "无"
+	}
+
+	paths := make([]string, 0, len(timeouts))
+	for path := range timeouts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	lines := make([]string, len(paths))
+	for i, path := range paths {
+		lines[i] = fmt.Sprintf("%s %d秒", path, timeouts[path])
+	}
+
+	return "\n  " + strings.Join(lines, "\n  ")
+}
+
+// formatNetworkConfig 格式化网络设置
+func formatNetworkConfig(config *MerchantConfig) string {
+	proxy := "无"
+	if config.Proxy != "" {
+		proxy = redactProxy(config.Proxy)
+	}
+
+	caCert := "系统默认"
+	if config.CACertFile != "" {
+		caCert = config.CACertFile
+	}
+
+	clientCert := "无"
+	if config.ClientCertFile != "" {
+		clientCert = config.ClientCertFile
+	}
+
+	tlsVersion := "默认"
+	if config.TLSMinVersion != "" {
+		tlsVersion = config.TLSMinVersion
+	}
+
+	msg := fmt.Sprintf("代理: %s\n"+
+		"根证书: %s\n"+
+		"客户端证书: %s\n"+
+		"TLS最低版本: %s",
+		proxy,
+		caCert,
+		clientCert,
+		tlsVersion)
+
+	if config.InsecureSkipVerify {
+		msg += "\n⚠️ 已跳过证书验证"
+	}
+
+	return msg
+}
+
+// formatBreakerState 格式化熔断器状态
+func formatBreakerState(state BreakerState) string {
+	switch state.State {
+	case breakerOpen:
+		wait := time.Until(state.RetryAt).Round(time.Second)
+		if wait < 0 {
+			wait = 0
+		}
+		return fmt.Sprintf("🔴 熔断中 (%s 后探测，熔断于 %s)", wait, state.OpenedAt.Format("15:04:05"))
+	case breakerHalfOpen:
+		return "🟡 探测中"
+	default:
+		if state.Failures > 0 {
+			return fmt.Sprintf("🟢 正常 (连续失败 %d 次)", state.Failures)
+		}
+		return "🟢 正常"
+	}
+}
+
+// formatSkewState 格式化时钟偏差
+func formatSkewState(state SkewState) string {
+	if state.SyncedAt.IsZero() {
+		return "未检测"
+	}
+
+	var offset string
+	switch {
+	case state.Offset == 0:
+		offset = "无偏差"
+	case state.Offset > 0:
+		offset = fmt.Sprintf("本机慢 %s", state.Offset)
+	default:
+		offset = fmt.Sprintf("本机快 %s", -state.Offset)
+	}
+
+	return fmt.Sprintf("%s (%s，同步于 %s)", offset, state.Source, state.SyncedAt.Format("15:04:05"))
+}
+
+// assignedIDs 获取分配到指定商户系统的群号或QQ号，按升序排列
+func assignedIDs(assignments map[int64]string, profile string) []int64 {
+	ids := make([]int64, 0)
+	for id, name := range assignments {
+		if name == profile {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// formatOrderStatus 格式化订单状态
+func formatOrderStatus(status int) string {
+	switch status {
+	case OrderStatusUnpaid:
+		return "⏳ 未支付"
+	case OrderStatusPaid:
+		return "✅ 已支付"
+	case OrderStatusClosed:
+		return "⛔ 已关闭"
+	case OrderStatusRefunded:
+		return "↩️ 已退款"
+	default:
+		return fmt.Sprintf("未知(%d)", status)
+	}
+}
+
+// formatNotifyStatus 格式化通知状态
+func formatNotifyStatus(status int) string {
+	switch status {
+	case NotifyStatusPending:
+		return "未通知"
+	case NotifyStatusSuccess:
+		return "通知成功"
+	case NotifyStatusFailed:
+		return "通知失败"
+	default:
+		return fmt.Sprintf("未知(%d)", status)
+	}
+}
+
+// formatRefundStatus 格式化退款状态
+func formatRefundStatus(status int) string {
+	switch status {
+	case RefundStatusProcessing:
+		return "⏳ 处理中"
+	case RefundStatusSuccess:
+		return "✅ 退款成功"
+	case RefundStatusFailed:
+		return "❌ 退款失败"
+	default:
+		return fmt.Sprintf("未知(%d)", status)
+	}
+}
+
+// formatLimitAmount 格式化限额，-1表示不限制
+func formatLimitAmount(limit int64) string {
+	if limit == -1 {
+		return "不限制"
+	}
+	return "¥" + formatAmount(limit)
+}
+
+// formatLimitCount 格式化数量限制，-1表示不限制
+func formatLimitCount(count int) string {
+	if count == -1 {
+		return "不限制"
+	}
+	return strconv.Itoa(count)
+}
+
+// formatRate 格式化费率(分)为百分比
+func formatRate(rate int) string {
+	return fmt.Sprintf("%.2f%%", float64(rate)/100)
+}
+
+// formatMealDays 格式化套餐有效期
+func formatMealDays(days int) string {
+	if days == -1 {
+		return "永久"
+	}
+	return fmt.Sprintf("%d天", days)
+}
+
+// formatExpireTime 格式化到期时间，-1表示永久
+func formatExpireTime(expireTime int64) string {
+	if expireTime == -1 {
+		return "永久"
+	}
+	return formatTime(expireTime)
+}
+
+// formatChannelStatus 格式化渠道账户启用状态
+func formatChannelStatus(status int) string {
+	if status == ChannelStatusEnabled {
+		return "启用"
+	}
+	return "禁用"
+}
+
+// formatChannelOnline 格式化渠道账户在线状态
+func formatChannelOnline(online int) string {
+	if online == ChannelOnline {
+		return "在线"
+	}
+	return "离线"
+}
+
+// progressBarWidth 进度条格数
+const progressBarWidth = 10
+
+// formatProgressBar 格式化额度使用进度条，limit<=0 表示不限额
+func formatProgressBar(used, limit int64) string {
+	if limit <= 0 {
+		return "不限额"
+	}
+
+	percent := float64(used) * 100 / float64(limit)
+	filled := int(used * progressBarWidth / limit)
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+	if filled < 0 {
+		filled = 0
+	}
+
+	return fmt.Sprintf("[%s%s] %.1f%%",
+		strings.Repeat("█", filled),
+		strings.Repeat("░", progressBarWidth-filled),
+		percent)
+}
+
+// displayAmount 显示金额，群聊中显示金额区间
+func displayAmount(amount int64, isPrivate bool) string {
+	if isPrivate {
+		return "¥" + formatAmount(amount)
+	}
+	return maskAmount(amount)
+}
+
+// displayAccountName 显示渠道账户名称，群聊中掩码处理
+func displayAccountName(name string, isPrivate bool) string {
+	if isPrivate {
+		return name
+	}
+	return maskAccountName(name)
+}
+
 // maskUserID 掩码用户ID
 func maskUserID(uid int64) string {
 	uidStr := strconv.FormatInt(uid, 10)
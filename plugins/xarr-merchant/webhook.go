@@ -0,0 +1,388 @@
+package xarrmerchant
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot/logger"
+)
+
+const (
+	// webhookConfigKey 到账回调服务配置存储key
+	webhookConfigKey = "merchant:webhook"
+	// webhookPath 回调地址路径，非默认商户系统在其后追加 /<名称>
+	webhookPath = "/xarr/notify"
+	// webhookDedupTTL 同一订单重复回调的去重时长
+	webhookDedupTTL = 24 * time.Hour
+	// webhookShutdownTimeout 关闭回调服务时等待请求处理完成的时间
+	webhookShutdownTimeout = 5 * time.Second
+	// tradeStatusSuccess 回调中表示支付成功的交易状态
+	tradeStatusSuccess = "TRADE_SUCCESS"
+)
+
+// WebhookConfig 到账回调服务配置，所有商户系统共用
+type WebhookConfig struct {
+	Listen string `json:"listen"` // 监听地址，如 :8090，为空表示关闭
+}
+
+// payTypeNames 回调中支付方式的显示名称
+var payTypeNames = map[string]string{
+	"alipay": "支付宝",
+	"wxpay":  "微信支付",
+	"qqpay":  "QQ钱包",
+}
+
+// webhookServer 到账回调HTTP服务
+type webhookServer struct {
+	mu     sync.Mutex
+	server *http.Server
+	listen string
+
+	seenMu   sync.Mutex
+	seen     map[string]time.Time // 已推送的订单号 → 推送时间
+	inflight map[string]bool      // 正在推送的订单号
+}
+
+// webhook 全局回调服务实例
+var webhook = newWebhookServer()
+
+// newWebhookServer 创建回调服务实例
+func newWebhookServer() *webhookServer {
+	return &webhookServer{
+		seen:     map[string]time.Time{},
+		inflight: map[string]bool{},
+	}
+}
+
+// loadWebhookConfig 读取回调服务配置
+func loadWebhookConfig() (*WebhookConfig, error) {
+	config := &WebhookConfig{}
+
+	data, err := storageDB.Get(webhookConfigKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// saveWebhookConfig 保存回调服务配置
+func saveWebhookConfig(config *WebhookConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return storageDB.Set(webhookConfigKey, data)
+}
+
+// webhookURLPath 指定商户系统的回调路径
+func webhookURLPath(profile string) string {
+	if profile == DefaultProfile {
+		return webhookPath
+	}
+	return webhookPath + "/" + profile
+}
+
+// start 在指定地址启动回调服务，新地址监听成功后再关闭旧服务
+func (w *webhookServer) start(listen string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 监听地址不变时需先释放端口
+	if listen == w.listen {
+		w.shutdownLocked()
+	}
+
+	// 先监听端口，端口被占用等错误直接返回，不影响正在运行的旧服务
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	w.shutdownLocked()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(webhookPath, w.handleNotify)
+	mux.HandleFunc(webhookPath+"/{profile}", w.handleNotify)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	w.server = server
+	w.listen = listen
+
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(fmt.Sprintf("到账回调服务异常退出: %s", err.Error()))
+		}
+	}()
+
+	logger.Info(fmt.Sprintf("到账回调服务已启动，监听 %s", listen))
+	return nil
+}
+
+// stop 关闭回调服务
+func (w *webhookServer) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.shutdownLocked()
+}
+
+// shutdownLocked 关闭回调服务，调用方需持有mu
+func (w *webhookServer) shutdownLocked() {
+	if w.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+	defer cancel()
+
+	if err := w.server.Shutdown(ctx); err != nil {
+		logger.Warn(fmt.Sprintf("关闭到账回调服务失败: %s", err.Error()))
+	}
+	logger.Info(fmt.Sprintf("到账回调服务已关闭 (%s)", w.listen))
+
+	w.server = nil
+	w.listen = ""
+}
+
+// 订单回调的去重结果
+const (
+	notifyNew      = iota // 首次回调，已占用，需推送后调用 finish
+	notifySeen            // 已推送过
+	notifyInflight        // 同一订单正在推送
+)
+
+// reserve 检查订单是否已推送或正在推送，否则将其标记为推送中
+// 检查和标记在同一把锁内完成，并发的重复回调只有一个能进入推送
+func (w *webhookServer) reserve(key string) int {
+	w.seenMu.Lock()
+	defer w.seenMu.Unlock()
+
+	now := time.Now()
+	for k, at := range w.seen {
+		if now.Sub(at) > webhookDedupTTL {
+			delete(w.seen, k)
+		}
+	}
+
+	if _, ok := w.seen[key]; ok {
+		return notifySeen
+	}
+	if w.inflight[key] {
+		return notifyInflight
+	}
+	w.inflight[key] = true
+	return notifyNew
+}
+
+// finish 结束推送，送达时记录为已推送；未送达时释放占用，由XArrPay重试
+func (w *webhookServer) finish(key string, delivered bool) {
+	w.seenMu.Lock()
+	defer w.seenMu.Unlock()
+
+	delete(w.inflight, key)
+	if delivered {
+		w.seen[key] = time.Now()
+	}
+}
+
+// verifyNotifySign 按商户系统配置的签名算法校验回调签名，sign和sign_type不参与签名，空值参数忽略
+func verifyNotifySign(params map[string]string, secret, signType string) bool {
+	sign := params["sign"]
+	if sign == "" || secret == "" {
+		return false
+	}
+
+	signed := make(map[string]string, len(params))
+	for k, v := range params {
+		if k == "sign" || k == "sign_type" || v == "" {
+			continue
+		}
+		signed[k] = v
+	}
+
+	expected := signParams(signed, secret, signType)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(sign)) == 1
+}
+
+// handleNotify 处理XArrPay订单通知回调
+// 处理成功或无需处理时返回 success，XArrPay 收到其它内容会重试
+// 有订阅但全部推送失败时返回 fail，使XArrPay稍后重试
+func (w *webhookServer) handleNotify(rw http.ResponseWriter, r *http.Request) {
+	profile := r.PathValue("profile")
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(rw, "fail", http.StatusBadRequest)
+		return
+	}
+
+	params := make(map[string]string, len(r.Form))
+	for k := range r.Form {
+		params[k] = r.Form.Get(k)
+	}
+
+	config, err := client.GetProfileConfig(profile)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("收到未知商户系统 %s 的回调", profile))
+		http.Error(rw, "fail", http.StatusNotFound)
+		return
+	}
+
+	if !verifyNotifySign(params, config.Secret, config.SignType) {
+		logger.Warn(fmt.Sprintf("到账回调验签失败 [%s] 来自 %s", profile, r.RemoteAddr))
+		http.Error(rw, "fail", http.StatusForbidden)
+		return
+	}
+
+	// 只推送支付成功的通知
+	if params["trade_status"] != tradeStatusSuccess {
+		fmt.Fprint(rw, "success")
+		return
+	}
+
+	tradeNo := params["trade_no"]
+	if tradeNo == "" {
+		tradeNo = params["out_trade_no"]
+	}
+	seenKey := profile + ":" + tradeNo
+	switch w.reserve(seenKey) {
+	case notifySeen:
+		fmt.Fprint(rw, "success")
+		return
+	case notifyInflight:
+		// 首个回调的推送结果未知，让XArrPay稍后重试，届时按推送结果处理
+		http.Error(rw, "fail", http.StatusConflict)
+		return
+	}
+
+	uid, _ := strconv.ParseInt(params["pid"], 10, 64)
+	subs, err := subscribersOf(profile, uid)
+	if err != nil {
+		w.finish(seenKey, false)
+		logger.Error(fmt.Sprintf("读取到账通知订阅失败: %s", err.Error()))
+		http.Error(rw, "fail", http.StatusInternalServerError)
+		return
+	}
+
+	delivered, attempted := pushPaymentNotify(subs, profile, uid, params)
+	if attempted > 0 && delivered == 0 {
+		w.finish(seenKey, false)
+		logger.Warn(fmt.Sprintf("订单 %s 的到账通知未能送达，等待商户系统重试", tradeNo))
+		http.Error(rw, "fail", http.StatusServiceUnavailable)
+		return
+	}
+
+	// 至少送达一处后记录，避免重试时重复推送给已送达的会话
+	w.finish(seenKey, true)
+	fmt.Fprint(rw, "success")
+}
+
+// formatPaymentNotify 格式化到账通知，群聊中金额脱敏且不显示商品名称
+func formatPaymentNotify(params map[string]string, isPrivate bool) string {
+	amount := "¥" + params["money"]
+	if fen, err := parseAmount(params["money"]); err == nil {
+		amount = displayAmount(fen, isPrivate)
+	}
+
+	payType := params["type"]
+	if name, ok := payTypeNames[payType]; ok {
+		payType = name
+	}
+
+	msg := fmt.Sprintf("🎉 收款到账\n\n"+
+		"金额: %s\n"+
+		"支付方式: %s\n"+
+		"商户订单号: %s",
+		amount,
+		payType,
+		params["out_trade_no"])
+	if isPrivate && params["name"] != "" {
+		msg += "\n商品名称: " + params["name"]
+	}
+
+	return msg
+}
+
+// pushPaymentNotify 向订阅了该商户的用户和群聊推送到账通知，同一群聊只推送一次
+// 返回成功送达和尝试推送的会话数
+func pushPaymentNotify(subs []Subscription, profile string, uid int64, params map[string]string) (delivered, attempted int) {
+	pushedGroups := map[int64]bool{}
+
+	for _, sub := range subs {
+		if sub.notifiesPrivate(profile, uid) {
+			attempted++
+			if err := pushPrivate(sub.UserID, formatPaymentNotify(params, true)); err != nil {
+				logger.Warn(fmt.Sprintf("推送到账通知给 %d 失败: %s", sub.UserID, err.Error()))
+			} else {
+				delivered++
+			}
+		}
+
+		for _, groupID := range sub.notifiedGroups(profile, uid) {
+			if pushedGroups[groupID] || !client.IsGroupAllowed(groupID) {
+				continue
+			}
+			pushedGroups[groupID] = true
+
+			attempted++
+			if err := pushGroup(groupID, formatPaymentNotify(params, false)); err != nil {
+				logger.Warn(fmt.Sprintf("推送到账通知到群 %d 失败: %s", groupID, err.Error()))
+			} else {
+				delivered++
+			}
+		}
+	}
+
+	return delivered, attempted
+}
+
+// formatWebhookState 格式化回调服务状态
+func formatWebhookState(profile string) string {
+	webhook.mu.Lock()
+	listen := webhook.listen
+	webhook.mu.Unlock()
+
+	if listen == "" {
+		return "未开启"
+	}
+	return fmt.Sprintf("监听 %s，路径 %s", listen, webhookURLPath(profile))
+}
+
+// startWebhook 按已保存的配置启动回调服务，机器人退出时自动关闭
+func startWebhook() {
+	config, err := loadWebhookConfig()
+	if err != nil {
+		logger.Error(fmt.Sprintf("读取到账回调配置失败: %s", err.Error()))
+		return
+	}
+
+	if config.Listen != "" {
+		if err := webhook.start(config.Listen); err != nil {
+			logger.Error(fmt.Sprintf("启动到账回调服务失败: %s", err.Error()))
+		}
+	}
+
+	go func() {
+		<-pluginCtx.Done()
+		webhook.stop()
+	}()
+}
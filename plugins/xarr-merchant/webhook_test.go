@@ -0,0 +1,198 @@
+package xarrmerchant
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeOneBot 模拟OneBot HTTP API，记录推送次数
+type fakeOneBot struct {
+	server *httptest.Server
+	sent   atomic.Int32
+	fail   atomic.Bool
+}
+
+// newFakeOneBot 启动模拟OneBot服务并设为推送接口
+func newFakeOneBot(t *testing.T) *fakeOneBot {
+	t.Helper()
+
+	bot := &fakeOneBot{}
+	bot.server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// 放慢推送，使并发回调在推送期间到达
+		time.Sleep(20 * time.Millisecond)
+		if bot.fail.Load() {
+			http.Error(rw, "offline", http.StatusBadGateway)
+			return
+		}
+		bot.sent.Add(1)
+		rw.Write([]byte(`{"status":"ok","retcode":0,"data":{"message_id":1}}`))
+	}))
+
+	if err := savePushConfig(&PushConfig{APIURL: bot.server.URL}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		bot.server.Close()
+		savePushConfig(&PushConfig{})
+	})
+
+	return bot
+}
+
+// setupNotifyTest 配置默认商户系统和一个开启私聊到账通知的订阅
+func setupNotifyTest(t *testing.T, userID, uid int64) {
+	t.Helper()
+
+	if err := client.SaveProfileConfig(DefaultProfile, &MerchantConfig{BaseURL: "http://127.0.0.1:1", Secret: "test_secret"}); err != nil {
+		t.Fatal(err)
+	}
+	err := updateSubscription(userID, func(sub *Subscription) {
+		sub.Profile = DefaultProfile
+		sub.UID = uid
+		sub.Private = true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { removeSubscription(userID) })
+}
+
+// notifyRequest 构造已签名的支付成功回调
+func notifyRequest(uid, tradeNo string) *http.Request {
+	params := map[string]string{
+		"pid":          uid,
+		"trade_no":     tradeNo,
+		"out_trade_no": "OUT" + tradeNo,
+		"type":         "alipay",
+		"money":        "1.00",
+		"trade_status": tradeStatusSuccess,
+	}
+	params["sign"] = generateSign(params, "test_secret")
+	params["sign_type"] = "MD5"
+
+	form := url.Values{}
+	for k, v := range params {
+		form.Set(k, v)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, webhookPath, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// TestHandleNotifyConcurrentDuplicates 并发的重复回调只推送一次
+func TestHandleNotifyConcurrentDuplicates(t *testing.T) {
+	bot := newFakeOneBot(t)
+	setupNotifyTest(t, 10001, 1001)
+	w := newWebhookServer()
+
+	const n = 10
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			w.handleNotify(rec, notifyRequest("1001", "T1001"))
+			codes[i] = rec.Code
+		}()
+	}
+	wg.Wait()
+
+	if got := bot.sent.Load(); got != 1 {
+		t.Fatalf("pushed %d times, want 1", got)
+	}
+	for i, code := range codes {
+		if code != http.StatusOK && code != http.StatusConflict {
+			t.Errorf("request %d: status %d, want 200 or 409", i, code)
+		}
+	}
+
+	// 推送完成后的重复回调直接返回success
+	rec := httptest.NewRecorder()
+	w.handleNotify(rec, notifyRequest("1001", "T1001"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "success" {
+		t.Errorf("late duplicate: %d %q, want 200 success", rec.Code, rec.Body.String())
+	}
+	if got := bot.sent.Load(); got != 1 {
+		t.Errorf("pushed %d times after late duplicate, want 1", got)
+	}
+}
+
+// TestHandleNotifyRetryAfterFailure 推送失败时返回fail，重试时重新推送
+func TestHandleNotifyRetryAfterFailure(t *testing.T) {
+	bot := newFakeOneBot(t)
+	setupNotifyTest(t, 10002, 1002)
+	w := newWebhookServer()
+
+	bot.fail.Store(true)
+	rec := httptest.NewRecorder()
+	w.handleNotify(rec, notifyRequest("1002", "T1002"))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("failed delivery: status %d, want 503", rec.Code)
+	}
+
+	bot.fail.Store(false)
+	rec = httptest.NewRecorder()
+	w.handleNotify(rec, notifyRequest("1002", "T1002"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "success" {
+		t.Fatalf("retry: %d %q, want 200 success", rec.Code, rec.Body.String())
+	}
+	if got := bot.sent.Load(); got != 1 {
+		t.Errorf("pushed %d times, want 1", got)
+	}
+}
+
+// TestVerifyNotifySign 回调按配置的签名算法校验，其他算法的签名被拒绝
+func TestVerifyNotifySign(t *testing.T) {
+	params := map[string]string{
+		"pid":          "1001",
+		"trade_no":     "T1",
+		"trade_status": tradeStatusSuccess,
+		"param":        "",
+	}
+	const secret = "test_secret"
+
+	signed := func(signType string) map[string]string {
+		p := map[string]string{}
+		for k, v := range params {
+			if v != "" {
+				p[k] = v
+			}
+		}
+		p["sign"] = signParams(p, secret, signType)
+
+		// 空值和sign_type不参与签名
+		p["param"] = ""
+		p["sign_type"] = "MD5"
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		signedBy string
+		want     bool
+	}{
+		{"md5", SignTypeMD5, SignTypeMD5, true},
+		{"hmac", SignTypeHMACSHA256, SignTypeHMACSHA256, true},
+		{"md5 forged for hmac", SignTypeHMACSHA256, SignTypeMD5, false},
+		{"sha256 for md5", SignTypeMD5, SignTypeSHA256, false},
+		{"unset defaults to md5", "", SignTypeMD5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyNotifySign(signed(tt.signedBy), secret, tt.config); got != tt.want {
+				t.Errorf("verifyNotifySign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}